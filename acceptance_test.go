@@ -1,14 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"talisman/detector"
 	"talisman/git_testing"
+	"talisman/scanner"
+	"talisman/utility"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +35,17 @@ fileignoreconfig:
   ignore_detectors: [filecontent]
 `
 
+const talismanRCDataWithPlugin = `
+plugins:
+- name: corp
+  command: sh
+  args:
+  - -c
+  - |
+    grep -q hunter2 && echo '{"path":"notes.txt","message":"Expected file to not to contain corporate passwords"}'
+    exit 0
+`
+
 const talismanRCDataWithFileNameAndCorrectChecksum = `
 fileignoreconfig:
 - filename: private.pem
@@ -88,6 +104,41 @@ func TestAddingSecretKeyShouldExitZeroIfPEMFileIsIgnored(t *testing.T) {
 	})
 }
 
+func TestRenamingIgnoredPEMFileShouldExitZero(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCDataWithFileNameAndCorrectChecksum)
+		git.AddAndcommit("*", "add private key")
+		git.ExecCommand("git", "mv", "private.pem", "renamed.pem")
+		git.ExecCommand("git", "commit", "-m", "rename private key")
+
+		wd, _ := os.Getwd()
+		os.Chdir(git.GetRoot())
+		defer func() { os.Chdir(wd) }()
+		previousCommit := git.ExecCommand("git", "rev-parse", "HEAD~1")
+		assert.Equal(t, 0, run(mockStdIn(previousCommit, git.LatestCommit()), options{githook: PrePush}), "Expected run() to return 0 as the pem file was ignored before its rename")
+	})
+}
+
+func TestPushingRenameOfIgnoredPEMFileShouldExitZeroWhateverTheWorkingTreeHolds(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCDataWithFileNameAndCorrectChecksum)
+		git.AddAndcommit("*", "add private key")
+		git.ExecCommand("git", "mv", "private.pem", "renamed.pem")
+		git.ExecCommand("git", "commit", "-m", "rename private key")
+		git.OverwriteFileContent("renamed.pem", "uncommitted change")
+
+		wd, _ := os.Getwd()
+		os.Chdir(git.GetRoot())
+		defer func() { os.Chdir(wd) }()
+		previousCommit := git.ExecCommand("git", "rev-parse", "HEAD~1")
+		assert.Equal(t, 0, run(mockStdIn(previousCommit, git.LatestCommit()), options{githook: PrePush}), "Expected run() to return 0 as the pushed content of the renamed pem file was ignored before its rename")
+	})
+}
+
 func TestAddingSecretKeyShouldExitOneIfPEMFileIsPresentInTheGitHistory(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		_options := options{
@@ -115,6 +166,165 @@ func TestScanningSimpleFileShouldExitZero(t *testing.T) {
 	})
 }
 
+func TestScanningHistoryWithSecretShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.CreateFileWithContents("another-file", "safe")
+		git.AddAndcommit("another-file", "add another file")
+
+		_options := options{scan: true}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as a pem file is present in the history")
+	})
+}
+
+func TestScanningRevisionRangeWithoutSecretShouldExitZero(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.RemoveFile("private.pem")
+		git.AddAndcommit("private.pem", "remove private key")
+
+		_options := options{scan: true, scanOptions: scanner.Options{Revisions: []string{"HEAD~1..HEAD"}}}
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the pem file is outside of the scanned range")
+	})
+}
+
+func TestScanningRevisionRangeShouldNotReportSecretsCommittedBeforeTheRange(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.CreateFileWithContents("another-file", "safe")
+		git.AddAndcommit("another-file", "add another file")
+
+		_options := options{scan: true, scanOptions: scanner.Options{Revisions: []string{"HEAD~1..HEAD"}}}
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the pem file was not changed in the scanned range")
+		_options = options{scan: true, scanOptions: scanner.Options{Revisions: []string{"HEAD~2..HEAD"}}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the pem file was added in the scanned range")
+	})
+}
+
+func TestScanningHistoryWithExcludedPathsShouldExitZero(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("keys/private.pem", "secret")
+		git.AddAndcommit("keys/private.pem", "add private key")
+
+		_options := options{scan: true, scanOptions: scanner.Options{ExcludePaths: []string{"keys/**"}}}
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the pem file is excluded from the scan")
+	})
+}
+
+func TestScanningLimitedNumberOfCommitsShouldOnlyScanLatestCommits(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.RemoveFile("private.pem")
+		git.AddAndcommit("private.pem", "remove private key")
+
+		_options := options{scan: true, scanOptions: scanner.Options{MaxCommits: 1}}
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as only the latest commit is scanned")
+	})
+}
+
+func TestScanningStashScopeShouldReportSecretsInOlderStashEntries(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.AppendFileContent("simple-file", awsAccessKeyIDExample)
+		git.ExecCommand("git", "stash")
+		git.AppendFileContent("simple-file", "more safe content")
+		git.ExecCommand("git", "stash")
+
+		assert.Equal(t, 0, runTalismanWithOptions(git, options{scan: true}), "Expected run() to return 0 as older stash entries are not scanned by default")
+		_options := options{scan: true, scanOptions: scanner.Options{Scopes: []string{scanner.StashScope}}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the secret is in a stash entry")
+	})
+}
+
+func TestScanningUnreachableScopeShouldReportSecretsInDanglingBlobs(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("leaked-file", awsAccessKeyIDExample)
+		git.ExecCommand("git", "hash-object", "-w", "leaked-file")
+		git.RemoveFile("leaked-file")
+
+		assert.Equal(t, 0, runTalismanWithOptions(git, options{scan: true}), "Expected run() to return 0 as unreachable objects are not scanned by default")
+		_options := options{scan: true, scanOptions: scanner.Options{Scopes: []string{scanner.UnreachableScope}}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the secret is in a dangling blob")
+	})
+}
+
+func TestScanningWithSubmodulesShouldReportSecretsInSubmoduleHistory(t *testing.T) {
+	withSubmoduleContainingSecret(func(git *git_testing.GitTesting) {
+		assert.Equal(t, 0, runTalismanWithOptions(git, options{scan: true}), "Expected run() to return 0 as submodules are not scanned by default")
+		_options := options{scan: true, recurseSubmodules: true, scanOptions: scanner.Options{NoCache: true}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the submodule history contains a pem file")
+	})
+}
+
+func TestScanningARangeWithSubmodulesShouldOnlyScanTheSubmoduleCommitsRecordedInTheRange(t *testing.T) {
+	withSubmoduleContainingSecret(func(git *git_testing.GitTesting) {
+		_options := options{scan: true, recurseSubmodules: true, scanOptions: scanner.Options{NoCache: true, Revisions: []string{"HEAD~1..HEAD"}}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the range adds the submodule with a pem file in its history")
+		git.CreateFileWithContents("notes.txt", "nothing to see here")
+		git.AddAndcommit("notes.txt", "add notes")
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the range does not change the submodule")
+	})
+}
+
+func TestPushingSubmoduleBumpShouldReportSecretsInNewSubmoduleCommits(t *testing.T) {
+	withSubmoduleContainingSecret(func(git *git_testing.GitTesting) {
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 as submodules are not verified by default")
+		_options := options{githook: PrePush, recurseSubmodules: true}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the new submodule commits contain a pem file")
+	})
+}
+
+func withSubmoduleContainingSecret(doGitOperation GitOperation) {
+	withNewTmpGitRepo(func(submodule *git_testing.GitTesting) {
+		submodule.SetupBaselineFiles("simple-file")
+		submodule.CreateFileWithContents("private.pem", "secret")
+		submodule.AddAndcommit("private.pem", "add private key")
+		withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+			git.SetupBaselineFiles("simple-file")
+			git.ExecCommand("git", "-c", "protocol.file.allow=always", "submodule", "add", "-q", submodule.GetRoot(), "sub")
+			git.ExecCommand("git", "commit", "-m", "add submodule")
+			doGitOperation(git)
+		})
+	})
+}
+
+func TestScanningHistoryTwiceShouldReportCachedFindings(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+
+		_options := options{scan: true}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the pem file is in the history")
+		_, err := os.Stat(filepath.Join(git.GetRoot(), ".git", "talisman", scanner.CacheFileName))
+		assert.Nil(t, err, "Expected the scan cache to be persisted")
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 from the cached findings of the pem file")
+	})
+}
+
+func TestScanningHistoryWithoutCacheShouldNotPersistCache(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+
+		_options := options{scan: true, scanOptions: scanner.Options{NoCache: true}}
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the pem file is in the history")
+		_, err := os.Stat(filepath.Join(git.GetRoot(), ".git", "talisman", scanner.CacheFileName))
+		assert.True(t, os.IsNotExist(err), "Expected no scan cache to be persisted")
+	})
+}
+
 func TestChecksumCalculatorShouldExitOne(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		_options := options{
@@ -161,6 +371,24 @@ func TestShouldExitZeroWhenNonSecretIsCommittedButFileContainsSecretPreviously(t
 	})
 }
 
+func TestPreCommitChecksumsAreCalculatedFromTheStagedContentRatherThanTheWorkingTree(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		_options := options{githook: PreCommit}
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("sample.txt", "password=somepassword \n")
+		git.Add("sample.txt")
+		git.OverwriteFileContent("sample.txt", "nothing to see here\n")
+
+		stagedChecksum := utility.CollectiveSHA256HashOfContents([]string{"sample.txt"}, [][]byte{[]byte("password=somepassword \n")})
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: sample.txt\n  checksum: "+stagedChecksum+"\n")
+		assert.Equal(t, 0, runTalismanWithOptions(git, _options), "Expected run() to return 0 as the checksum is that of the staged content")
+
+		workingTreeChecksum := utility.CollectiveSHA256HashOfContents([]string{"sample.txt"}, [][]byte{git.FileContents("sample.txt")})
+		git.OverwriteFileContent(".talismanrc", "fileignoreconfig:\n- filename: sample.txt\n  checksum: "+workingTreeChecksum+"\n")
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the checksum is that of the working tree, not of the staged content")
+	})
+}
+
 // Need to work on this test case as talismanrc does  not yet support comments
 // func TestAddingSecretKeyShouldExitZeroIfPEMFilesAreIgnoredAndCommented(t *testing.T) {
 // 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
@@ -210,6 +438,38 @@ func TestStagingSecretKeyShouldExitOneWhenPreCommitFlagIsSet(t *testing.T) {
 	})
 }
 
+func TestStagingSecretKeyShouldWriteJSONResultsToTheOutputFile(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+		outputFile := filepath.Join(git.GetRoot(), "..", "talisman-output.json")
+		defer os.Remove(outputFile)
+
+		_options := options{
+			githook:    PreCommit,
+			outputFile: outputFile,
+		}
+		stdout, _ := ioutil.TempFile("", "talisman-stdout")
+		defer os.Remove(stdout.Name())
+		realStdout := os.Stdout
+		os.Stdout = stdout
+		exitStatus := runTalismanWithOptions(git, _options)
+		os.Stdout = realStdout
+		stdout.Close()
+
+		assert.Equal(t, 1, exitStatus, "Expected run() to return 1 and fail as pem file was present in the repo")
+		printed, _ := ioutil.ReadFile(stdout.Name())
+		assert.Empty(t, string(printed), "Expected the tables to go to stderr as results are written for tools")
+		contents, err := ioutil.ReadFile(outputFile)
+		assert.NoError(t, err, "Expected the results to be written to the output file")
+		var results detector.DetectionResults
+		assert.NoError(t, json.Unmarshal(contents, &results), "Expected the results in the schema of report.json")
+		assert.True(t, results.HasFailures())
+		assert.Equal(t, "private.pem", string(results.Results[0].Filename))
+	})
+}
+
 func TestPatternFindsSecretKey(t *testing.T) {
 	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
 		_options := options{
@@ -252,6 +512,157 @@ func TestPatternFindsSecretInNestedFile(t *testing.T) {
 	})
 }
 
+func TestScanningDirectoryWithSecretShouldExitOne(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "docs/readme.txt", "nothing to see here")
+		writeFile(dir, "config/settings.properties", awsAccessKeyIDExample)
+
+		assert.Equal(t, 1, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 1 as a nested file contains a secret")
+	})
+}
+
+func TestScanningDirectoryShouldSkipExcludedFiles(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, ".gitignore", "build/\n*.log\n")
+		writeFile(dir, "build/settings.properties", awsAccessKeyIDExample)
+		writeFile(dir, "nested/debug.log", awsAccessKeyIDExample)
+		writeFile(dir, "nested/.talismanignore", "generated.txt\n")
+		writeFile(dir, "nested/generated.txt", awsAccessKeyIDExample)
+		writeFile(dir, "readme.txt", "nothing to see here")
+
+		assert.Equal(t, 0, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 0 as only excluded files contain secrets")
+	})
+}
+
+func TestScanningDirectoryShouldApplyTheBinaryPolicy(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "image.bin", "\x00\x01"+awsAccessKeyIDExample)
+
+		assert.Equal(t, 1, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 1 as the strings of binary files are scanned by default")
+		writeFile(dir, ".talismanrc", "binary:\n  policy: skip\n")
+		assert.Equal(t, 0, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 0 as binary files are skipped by the policy")
+	})
+}
+
+func TestScanningDirectoryShouldFailOnAnInvalidTalismanRC(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "readme.txt", "nothing to see here")
+		writeFile(dir, ".talismanrc", "entropy:\n  hex_threshold: -1\n")
+
+		assert.Equal(t, 1, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 1 as the .talismanrc is invalid")
+	})
+}
+
+func TestScanningDirectoryShouldTerminateOnSymlinkLoops(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "nested/readme.txt", "nothing to see here")
+		os.Symlink("..", filepath.Join(dir, "nested", "parent"))
+
+		assert.Equal(t, 0, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 0 and not to loop forever")
+	})
+}
+
+func TestScanningDirectoryShouldFailOnUnreadableFiles(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions do not restrict root")
+	}
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "readme.txt", "nothing to see here")
+		os.Chmod(filepath.Join(dir, "readme.txt"), 0)
+
+		assert.Equal(t, 1, runTalismanInDirectory(dir, options{scanDirectory: "."}), "Expected run() to return 1 as a file could not be verified")
+	})
+}
+
+func TestScanningStdinWithSecretShouldExitOne(t *testing.T) {
+	withNewTmpDirNamed("talisman-stdin-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		_options := options{readStdin: true, stdinName: "secret.yaml"}
+
+		assert.Equal(t, 1, runTalismanInDirectoryWithStdin(dir, _options, awsAccessKeyIDExample), "Expected run() to return 1 as the piped content contains a secret")
+		assert.Equal(t, 0, runTalismanInDirectoryWithStdin(dir, _options, "nothing to see here"), "Expected run() to return 0 as the piped content is harmless")
+	})
+}
+
+func TestScanningStdinShouldHonourIgnoresOfItsName(t *testing.T) {
+	withNewTmpDirNamed("talisman-stdin-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		assert.Equal(t, 1, runTalismanInDirectoryWithStdin(dir, options{readStdin: true, stdinName: "private.pem"}, "secret"), "Expected run() to return 1 as the piped content is named like a private key")
+
+		writeFile(dir, ".talismanrc", talismanRCDataWithIgnoreDetectorWithFilename)
+		assert.Equal(t, 0, runTalismanInDirectoryWithStdin(dir, options{readStdin: true, stdinName: "private.pem"}, "secret"), "Expected run() to return 0 as the filename detector is ignored for private.pem")
+	})
+}
+
+func TestScanningStdinShouldNotBeIgnoredByTheChecksumOfAFileOnDisk(t *testing.T) {
+	withNewTmpDirNamed("talisman-stdin-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "secret.yaml", "nothing to see here")
+		checksum := utility.CollectiveSHA256HashOfContents([]string{"secret.yaml"}, [][]byte{[]byte("nothing to see here")})
+		writeFile(dir, ".talismanrc", "fileignoreconfig:\n- filename: secret.yaml\n  checksum: "+checksum+"\n")
+
+		assert.Equal(t, 1, runTalismanInDirectoryWithStdin(dir, options{readStdin: true, stdinName: "secret.yaml"}, awsAccessKeyIDExample), "Expected run() to return 1 as the checksum is that of the file on disk, not of the piped content")
+	})
+}
+
+func TestScanningFileListShouldOnlyScanListedFiles(t *testing.T) {
+	withNewTmpDirNamed("talisman-files-from-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "config/settings.properties", awsAccessKeyIDExample)
+		writeFile(dir, "readme.txt", "nothing to see here")
+		writeFile(dir, "docs/file with spaces.txt", "nothing to see here")
+		writeFile(dir, "harmless.list", "readme.txt\x00docs/file with spaces.txt\x00")
+
+		assert.Equal(t, 0, runTalismanInDirectory(dir, options{filesFrom: "harmless.list"}), "Expected run() to return 0 as only harmless files are listed")
+		assert.Equal(t, 1, runTalismanInDirectoryWithStdin(dir, options{filesFrom: "-"}, "readme.txt\x00config/settings.properties"), "Expected run() to return 1 as a listed file contains a secret")
+		assert.Equal(t, 1, runTalismanInDirectoryWithStdin(dir, options{filesFrom: "-"}, "missing.txt\x00"), "Expected run() to return 1 as a listed file could not be read")
+	})
+}
+
+func TestPluginsConfiguredInTalismanRCShouldReportFindings(t *testing.T) {
+	withNewTmpDirNamed("talisman-plugin-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, ".talismanrc", talismanRCDataWithPlugin)
+		writeFile(dir, "notes.txt", "the password is hunter2")
+
+		assert.Equal(t, 1, runTalismanInDirectory(dir, options{pattern: "notes.txt", plugins: true}), "Expected run() to return 1 as the plugin reports a finding")
+		assert.Equal(t, 0, runTalismanInDirectory(dir, options{pattern: "notes.txt"}), "Expected run() to return 0 as plugins are only run when enabled")
+	})
+}
+
+func TestPatternWithJUnitOutputFormatFindsSecretKey(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		_options := options{
+			pattern:      "./*.*",
+			outputFormat: "junit",
+		}
+
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 and fail as pem file was present in the repo")
+	})
+}
+
+func TestUnknownOutputFormatShouldExitOne(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		_options := options{
+			pattern:      "./*.*",
+			outputFormat: "yaml",
+		}
+
+		git.SetupBaselineFiles("simple-file")
+
+		assert.Equal(t, 1, runTalismanWithOptions(git, _options), "Expected run() to return 1 as the output format is not supported")
+	})
+}
+
 func runTalisman(git *git_testing.GitTesting) int {
 	_options := options{
 		debug:   false,
@@ -267,6 +678,23 @@ func runTalismanWithOptions(git *git_testing.GitTesting, _options options) int {
 	return run(mockStdIn(git.EarliestCommit(), git.LatestCommit()), _options)
 }
 
+func runTalismanInDirectory(dir string, _options options) int {
+	return runTalismanInDirectoryWithStdin(dir, _options, "")
+}
+
+func runTalismanInDirectoryWithStdin(dir string, _options options, stdin string) int {
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer func() { os.Chdir(wd) }()
+	return run(strings.NewReader(stdin), _options)
+}
+
+func writeFile(dir string, relativePath string, contents string) {
+	filePath := filepath.Join(dir, relativePath)
+	os.MkdirAll(filepath.Dir(filePath), 0755)
+	ioutil.WriteFile(filePath, []byte(contents), 0644)
+}
+
 type Operation func(dirName string)
 
 func withNewTmpDirNamed(dirName string, operation Operation) {
@@ -0,0 +1,78 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"talisman/git_repo"
+)
+
+//Allowlist lists the values known not to be secrets, e.g. documented example keys or the integrity hashes of dependencies.
+//The content detectors ignore the secrets it allows, whichever file they are found in.
+type Allowlist struct {
+	//Values are allowed when a secret equals one of them
+	Values []string `yaml:"values,omitempty"`
+	//Hashes are the sha256 of allowed secrets, either in full or as the 16 characters shown in the reports, optionally prefixed with "sha256:"
+	Hashes []string `yaml:"hashes,omitempty"`
+	//Regexes are allowed when a secret contains a match of one of them
+	Regexes []string `yaml:"regexes,omitempty"`
+	//compiledRegexes are the Regexes as compiled by Validate
+	compiledRegexes []*regexp.Regexp
+}
+
+//Allows answers whether the secret is allowlisted by any of the values, hashes or regexes.
+//The regexes are only applied once Validate has compiled them, which ParseTalismanRCIgnore does.
+func (a Allowlist) Allows(secret string) bool {
+	for _, value := range a.Values {
+		if secret == value {
+			return true
+		}
+	}
+	if len(a.Hashes) > 0 {
+		sum := sha256.Sum256([]byte(secret))
+		hash := hex.EncodeToString(sum[:])
+		for _, allowed := range a.Hashes {
+			allowed = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(allowed), "sha256:"))
+			if allowed == hash || allowed == hash[:secretHashLength] {
+				return true
+			}
+		}
+	}
+	for _, re := range a.compiledRegexes {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+//Validate compiles the regexes of the allowlist, returning an error naming the first one that does not compile
+func (a *Allowlist) Validate() error {
+	var compiled []*regexp.Regexp
+	for _, pattern := range a.Regexes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid allowlist regex %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	a.compiledRegexes = compiled
+	return nil
+}
+
+//ignoreIfAllowed records the secret found in the file as ignored for the category when the allowlist allows it, and answers whether it did
+func (a Allowlist) ignoreIfAllowed(secret string, filePath git_repo.FilePath, category string, result *DetectionResults) bool {
+	if !a.Allows(secret) {
+		return false
+	}
+	result.Logger().WithFields(log.Fields{
+		"filePath": filePath,
+		"category": category,
+	}).Info("Ignoring secret as it is allowlisted in .talismanrc.")
+	result.Ignore(filePath, category)
+	return true
+}
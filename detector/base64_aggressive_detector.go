@@ -9,12 +9,13 @@ var delimiters = []string{".", "-", "="}
 const aggressivenessThreshold = 15 //decreasing makes it more aggressive
 
 type Base64AggressiveDetector struct {
+	threshold int
 }
 
 func (ac *Base64AggressiveDetector) Test(s string) string {
 	for _, d := range delimiters {
 		subStrings := strings.Split(s, d)
-		res := checkEachSubString(subStrings)
+		res := checkEachSubString(subStrings, ac.threshold)
 		if res != "" {
 			return res
 		}
@@ -22,8 +23,8 @@ func (ac *Base64AggressiveDetector) Test(s string) string {
 	return ""
 }
 
-func decodeBase64(s string) string {
-	if len(s) <= aggressivenessThreshold {
+func decodeBase64(s string, threshold int) string {
+	if len(s) <= threshold {
 		return ""
 	}
 	_, err := base64.StdEncoding.DecodeString(s)
@@ -33,9 +34,9 @@ func decodeBase64(s string) string {
 	return s
 }
 
-func checkEachSubString(subStrings []string) string {
+func checkEachSubString(subStrings []string, threshold int) string {
 	for _, sub := range subStrings {
-		suspicious := decodeBase64(sub)
+		suspicious := decodeBase64(sub, threshold)
 		if suspicious != "" {
 			return suspicious
 		}
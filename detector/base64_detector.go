@@ -9,6 +9,9 @@ type Base64Detector struct {
 	aggressiveDetector *Base64AggressiveDetector
 	entropy *Entropy
 	wordCheck *WordCheck
+	//threshold and minLength override BASE64_ENTROPY_THRESHOLD and MIN_BASE64_SECRET_LENGTH when set
+	threshold *float64
+	minLength *int
 }
 
 func NewBase64Detector() *Base64Detector {
@@ -27,10 +30,10 @@ func (bd *Base64Detector) initBase64Map() {
 }
 
 func (bd *Base64Detector) checkBase64Encoding(word string) string {
-	entropyCandidates := bd.entropy.GetEntropyCandidatesWithinWord(word, MIN_BASE64_SECRET_LENGTH, bd.base64Map)
+	entropyCandidates := bd.entropy.GetEntropyCandidatesWithinWord(word, bd.minimumLength(), bd.base64Map)
 	for _, candidate := range entropyCandidates {
 		entropy := bd.entropy.GetShannonEntropy(candidate, BASE64_CHARS)
-		if entropy > BASE64_ENTROPY_THRESHOLD && !bd.wordCheck.containsWordsOnly(candidate) {
+		if entropy > bd.entropyThreshold() && !bd.wordCheck.containsWordsOnly(candidate) {
 			return word
 		}
 	}
@@ -40,3 +43,16 @@ func (bd *Base64Detector) checkBase64Encoding(word string) string {
 	return ""
 }
 
+func (bd *Base64Detector) entropyThreshold() float64 {
+	if bd.threshold == nil {
+		return BASE64_ENTROPY_THRESHOLD
+	}
+	return *bd.threshold
+}
+
+func (bd *Base64Detector) minimumLength() int {
+	if bd.minLength == nil {
+		return MIN_BASE64_SECRET_LENGTH
+	}
+	return *bd.minLength
+}
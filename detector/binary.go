@@ -0,0 +1,164 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path"
+
+	log "github.com/Sirupsen/logrus"
+	"talisman/git_repo"
+)
+
+//The binary policies decide how the content detectors handle binary files
+const (
+	//BinarySkip leaves binary files unscanned
+	BinarySkip = "skip"
+	//BinaryStrings scans the printable strings of binary files only, the default
+	BinaryStrings = "strings"
+	//BinaryFail fails binary files, without scanning them
+	BinaryFail = "fail"
+)
+
+//sniffLength is the number of leading bytes looked at to tell binary files from text files, as git does
+const sniffLength = 8000
+
+//minPrintableLength is the length a run of printable characters must have to be scanned as a string of a binary file
+const minPrintableLength = 6
+
+//objectFilePatterns are the names of executables and object files, whose formats cannot all be told from their content.
+//Only binary files are told apart by their name, as text files such as linker scripts share these names.
+var objectFilePatterns = []string{"*.exe", "*.dll", "*.so", "*.so.*", "*.dylib", "*.o", "*.obj", "*.a", "*.lib", "*.class", "*.pyc", "*.wasm"}
+
+//BinaryFileConfig overrides the binary policy for the files matching its pattern
+type BinaryFileConfig struct {
+	Pattern string `yaml:"pattern"`
+	Policy  string `yaml:"policy"`
+}
+
+//BinaryConfig holds the binary policy of the whole repository, and the overrides for files.
+//The overrides of every pattern matching a file apply in order, so later ones take precedence.
+type BinaryConfig struct {
+	Policy string             `yaml:"policy,omitempty"`
+	Files  []BinaryFileConfig `yaml:"files,omitempty"`
+}
+
+//PolicyFor returns the binary policy that applies to the file of the addition.
+//An unknown policy is treated as BinaryFail, so that a misspelt policy does not let binary files through unscanned.
+func (c BinaryConfig) PolicyFor(addition git_repo.Addition) string {
+	policy := c.Policy
+	for _, file := range c.Files {
+		if file.Pattern != "" && addition.Matches(file.Pattern) {
+			policy = file.Policy
+		}
+	}
+	switch policy {
+	case "":
+		return BinaryStrings
+	case BinarySkip, BinaryStrings, BinaryFail:
+		return policy
+	default:
+		return BinaryFail
+	}
+}
+
+//Validate returns an error naming the first policy that is not one of BinarySkip, BinaryStrings and BinaryFail
+func (c BinaryConfig) Validate() error {
+	policies := []string{c.Policy}
+	for _, file := range c.Files {
+		policies = append(policies, file.Policy)
+	}
+	for _, policy := range policies {
+		if policy != "" && policy != BinarySkip && policy != BinaryStrings && policy != BinaryFail {
+			return fmt.Errorf("unknown binary policy %q, expected one of %v", policy, []string{BinarySkip, BinaryStrings, BinaryFail})
+		}
+	}
+	return nil
+}
+
+//contentToScan returns the addition with the content the content detectors are to scan according to the binary policy,
+//and false if they are not to scan it at all, in which case the file is recorded as ignored for the category.
+//Binary files failed by the policy are not scanned either, as the binaryfile detector fails them.
+func (c BinaryConfig) contentToScan(addition git_repo.Addition, category string, result *DetectionResults) (git_repo.Addition, bool) {
+	if !IsBinary(addition.Data) {
+		return addition, true
+	}
+	policy := c.PolicyFor(addition)
+	switch policy {
+	case BinaryStrings:
+		addition.Data = printableStrings(addition.Data)
+		return addition, true
+	case BinarySkip, BinaryFail:
+		result.Logger().WithFields(log.Fields{
+			"filePath": addition.Path,
+			"policy":   policy,
+		}).Info("Not scanning the content of binary file.")
+		result.Ignore(addition.Path, category)
+	}
+	return addition, false
+}
+
+//IsBinary answers whether the data is the content of a binary file, which like git it considers it to be when there is a NUL byte in its first 8000 bytes
+func IsBinary(data []byte) bool {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+//printableStrings returns the runs of printable ASCII characters of the data, one per line, leaving out runs too short to hold a secret
+func printableStrings(data []byte) []byte {
+	var result bytes.Buffer
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end-start >= minPrintableLength {
+			result.Write(data[start:end])
+			result.WriteByte('\n')
+		}
+		start = -1
+	}
+	for i, b := range data {
+		if b == '\t' || (b >= 0x20 && b < 0x7f) {
+			if start < 0 {
+				start = i
+			}
+		} else {
+			flush(i)
+		}
+	}
+	flush(len(data))
+	return result.Bytes()
+}
+
+//executableFormat returns the format of the executable or object file held by the addition, or "" if it holds none
+func executableFormat(addition git_repo.Addition) string {
+	data := addition.Data
+	switch {
+	case bytes.HasPrefix(data, []byte("\x7fELF")):
+		return "an ELF binary"
+	case bytes.HasPrefix(data, []byte("MZ")) && IsBinary(data):
+		return "a Windows executable"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xed, 0xfa, 0xce}), bytes.HasPrefix(data, []byte{0xfe, 0xed, 0xfa, 0xcf}),
+		bytes.HasPrefix(data, []byte{0xce, 0xfa, 0xed, 0xfe}), bytes.HasPrefix(data, []byte{0xcf, 0xfa, 0xed, 0xfe}):
+		return "a Mach-O binary"
+	case bytes.HasPrefix(data, []byte{0xca, 0xfe, 0xba, 0xbe}) && len(data) >= 8:
+		//universal Mach-O binaries share their magic number with Java classes, but hold far fewer architectures than any class file version
+		if binary.BigEndian.Uint32(data[4:8]) < 45 {
+			return "a Mach-O universal binary"
+		}
+		return "a Java class file"
+	case bytes.HasPrefix(data, []byte("!<arch>\n")):
+		return "an archive of object files"
+	case bytes.HasPrefix(data, []byte("\x00asm")):
+		return "a WebAssembly module"
+	}
+	if !IsBinary(data) {
+		return ""
+	}
+	for _, pattern := range objectFilePatterns {
+		if matched, _ := path.Match(pattern, string(addition.Name)); matched {
+			return fmt.Sprintf("an executable or object file by its name (%s)", pattern)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package detector
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func binaryWithSecret() []byte {
+	return []byte("\x00\x01\x02\xffJFIF\x00\x10\x80 key=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY\x00\x05\x7f\xfe")
+}
+
+func TestShouldTellBinaryFilesFromTextFiles(t *testing.T) {
+	pixel, err := ioutil.ReadFile("../git_repo/pixel.jpg")
+	assert.NoError(t, err)
+
+	assert.True(t, IsBinary(pixel))
+	assert.False(t, IsBinary([]byte("plain text\nwith two lines\n")))
+}
+
+func TestShouldScanPrintableStringsOfBinaryFilesByDefault(t *testing.T) {
+	additions := []git_repo.Addition{git_repo.NewAddition("image.jpg", binaryWithSecret())}
+
+	results := NewDetectionResults()
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures())
+	assert.Len(t, results.Results[0].FailureList, 1, "Expected the secret to be found in the printable strings only")
+}
+
+func TestShouldApplyTheBinaryPolicyOfFiles(t *testing.T) {
+	rc, err := ParseTalismanRCIgnore([]byte("binary:\n  policy: skip\n  files:\n  - pattern: \"*.bin\"\n    policy: fail\n"))
+	assert.NoError(t, err)
+	additions := []git_repo.Addition{
+		git_repo.NewAddition("image.jpg", binaryWithSecret()),
+		git_repo.NewAddition("firmware.bin", binaryWithSecret()),
+	}
+
+	results := NewDetectionResults()
+	DefaultChain().Test(additions, rc, results)
+
+	assert.Len(t, results.Results, 2)
+	assert.Empty(t, results.Results[0].FailureList, "Expected the skipped binary file not to be scanned")
+	assert.Len(t, results.Results[1].FailureList, 1, "Expected the binary file to be failed without being scanned")
+	assert.Equal(t, BinaryPolicyRuleID, results.Results[1].FailureList[0].RuleID)
+}
+
+func TestShouldFailBinaryFilesOfAnUnknownPolicy(t *testing.T) {
+	rc := TalismanRCIgnore{Binary: BinaryConfig{Policy: BinarySkip, Files: []BinaryFileConfig{{Pattern: "*.bin", Policy: "fial"}}}}
+	additions := []git_repo.Addition{git_repo.NewAddition("firmware.bin", binaryWithSecret())}
+
+	assert.Equal(t, BinaryFail, rc.Binary.PolicyFor(additions[0]))
+	results := NewDetectionResults()
+	DefaultChain().Test(additions, rc, results)
+
+	assert.True(t, results.HasFailures(), "Expected the binary file to be failed rather than let through")
+	assert.Len(t, results.Results[0].FailureList, 1, "Expected the binary file to be failed without being scanned")
+	assert.Equal(t, BinaryPolicyRuleID, results.Results[0].FailureList[0].RuleID)
+}
+
+func TestParsingTalismanRCShouldRejectUnknownBinaryPolicies(t *testing.T) {
+	_, err := ParseTalismanRCIgnore([]byte("binary:\n  policy: scan\n"))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package detector
+
+import (
+	"fmt"
+
+	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//BinaryFileDetector fails executables and object files, which have no place in a repository and can hide secrets compiled into them,
+//as well as the binary files the binary policy of .talismanrc fails
+type BinaryFileDetector struct{}
+
+//NewBinaryFileDetector returns a BinaryFileDetector
+func NewBinaryFileDetector() Detector {
+	return BinaryFileDetector{}
+}
+
+//Test fails the executables, object files and binary files to be failed among the additions
+func (bd BinaryFileDetector) Test(additions []git_repo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
+	cc := NewChecksumCompare(additions, ignoreConfig)
+	for _, addition := range additions {
+		if ignoreConfig.Deny(addition, "binaryfile") || cc.IsScanNotRequired(addition) {
+			result.Logger().WithFields(log.Fields{
+				"filePath": addition.Path,
+			}).Info("Ignoring addition as it was specified to be ignored.")
+			result.Ignore(addition.Path, "binaryfile")
+			continue
+		}
+		if format := executableFormat(addition); format != "" {
+			result.Logger().WithFields(log.Fields{
+				"filePath": addition.Path,
+			}).Info("Failing file as it is an executable or object file.")
+			result.FailWithRule(addition.Path, "binaryfile", ExecutableRuleID, fmt.Sprintf("Expected file to not to be an executable or object file, but it is %s", format), addition.Commits)
+		} else if IsBinary(addition.Data) && ignoreConfig.Binary.PolicyFor(addition) == BinaryFail {
+			result.Logger().WithFields(log.Fields{
+				"filePath": addition.Path,
+			}).Info("Failing file as binary files are failed by the binary policy.")
+			result.FailWithRule(addition.Path, "binaryfile", BinaryPolicyRuleID, "Expected file to not to be binary, as the binary policy of .talismanrc fails it", addition.Commits)
+		}
+	}
+}
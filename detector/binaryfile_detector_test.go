@@ -0,0 +1,41 @@
+package detector
+
+import (
+	"testing"
+
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldFailExecutablesAndObjectFiles(t *testing.T) {
+	additions := []git_repo.Addition{
+		git_repo.NewAddition("bin/talisman", []byte("\x7fELF\x02\x01\x01\x00\x00\x00")),
+		git_repo.NewAddition("Main.class", []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x34}),
+		git_repo.NewAddition("build/main.o", []byte("\x00not sniffable")),
+		git_repo.NewAddition("README.md", []byte("MZ is how Windows executables start")),
+		git_repo.NewAddition("lib/libc.so", []byte("/* GNU ld script */\nGROUP ( /lib/libc.so.6 )\n")),
+	}
+
+	results := NewDetectionResults()
+	NewBinaryFileDetector().Test(additions, TalismanRCIgnore{}, results)
+
+	assert.Len(t, results.Results, 3)
+	assert.Contains(t, results.Results[0].FailureList[0].Message, "an ELF binary")
+	assert.Contains(t, results.Results[1].FailureList[0].Message, "a Java class file")
+	assert.Contains(t, results.Results[2].FailureList[0].Message, "*.o")
+	assert.Equal(t, ExecutableRuleID, results.Results[0].FailureList[0].RuleID)
+}
+
+func TestShouldNotFailImagesOrIgnoredExecutables(t *testing.T) {
+	additions := []git_repo.Addition{
+		git_repo.NewAddition("pixel.jpg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")),
+		git_repo.NewAddition("tools/helper.exe", []byte("MZ\x90\x00\x03\x00")),
+	}
+	ignores := TalismanRCIgnore{FileIgnoreConfig: []FileIgnoreConfig{{FileName: "tools/helper.exe", IgnoreDetectors: []string{"binaryfile"}}}}
+
+	results := NewDetectionResults()
+	NewBinaryFileDetector().Test(additions, ignores, results)
+
+	assert.False(t, results.HasFailures())
+}
@@ -17,11 +17,14 @@ func NewChecksumCompare(gitAdditions []git_repo.Addition, talismanRCIgnoreConfig
 }
 
 func (cc *ChecksumCompare) IsScanNotRequired(addition git_repo.Addition) bool {
-	currentCollectiveChecksum := utility.CollectiveSHA256Hash([]string{string(addition.Path)})
+	if addition.IsPureRename() && cc.isApprovedBeforeRename(addition) {
+		return true
+	}
+	currentCollectiveChecksum := checksumOf(addition, string(addition.Path))
 	declaredCheckSum := ""
 	for _, ignore := range cc.ignoreConfig.FileIgnoreConfig {
 		if addition.Matches(ignore.FileName) {
-			currentCollectiveChecksum = utility.CollectiveSHA256Hash([]string{ignore.FileName})
+			currentCollectiveChecksum = checksumOf(addition, ignore.FileName)
 			declaredCheckSum = ignore.Checksum
 		}
 
@@ -30,6 +33,22 @@ func (cc *ChecksumCompare) IsScanNotRequired(addition git_repo.Addition) bool {
 
 }
 
+//isApprovedBeforeRename answers whether the unchanged content of a renamed file was approved with a checksum under the path it was renamed from.
+func (cc *ChecksumCompare) isApprovedBeforeRename(addition git_repo.Addition) bool {
+	for _, ignore := range cc.ignoreConfig.FileIgnoreConfig {
+		if ignore.FileName == string(addition.OldPath) && ignore.Checksum == checksumOf(addition, ignore.FileName) {
+			return true
+		}
+	}
+	return false
+}
+
+//checksumOf returns the checksum of the content the addition was read from, e.g. the staged or committed blob, as declared in .talismanrc for the path.
+//The working tree is never read, as it may differ from the content being checked.
+func checksumOf(addition git_repo.Addition, path string) string {
+	return utility.CollectiveSHA256HashOfContents([]string{path}, [][]byte{addition.WholeContent()})
+}
+
 //FilterIgnoresBasedOnChecksums filters the file ignores from the TalismanRCIgnore which doesn't have any checksum value or having mismatched checksum value from the .talsimanrc
 func (cc *ChecksumCompare) FilterIgnoresBasedOnChecksums() TalismanRCIgnore {
 	finalIgnores := []FileIgnoreConfig{}
@@ -47,16 +66,26 @@ func (cc *ChecksumCompare) FilterIgnoresBasedOnChecksums() TalismanRCIgnore {
 
 func (cc *ChecksumCompare) calculateCollectiveChecksumForPattern(fileNamePattern string, additions []git_repo.Addition) string {
 	var patternpaths []string
+	var contents [][]byte
 	currentCollectiveChecksum := ""
 	for _, addition := range additions {
-		if addition.Matches(fileNamePattern) {
+		if addition.Matches(fileNamePattern) && !containsPath(patternpaths, string(addition.Path)) {
 			patternpaths = append(patternpaths, string(addition.Path))
+			contents = append(contents, addition.WholeContent())
 		}
 	}
 	// Calculate current collective checksum
-	patternpaths = utility.UniqueItems(patternpaths)
 	if len(patternpaths) != 0 {
-		currentCollectiveChecksum = utility.CollectiveSHA256Hash(patternpaths)
+		currentCollectiveChecksum = utility.CollectiveSHA256HashOfContents(patternpaths, contents)
 	}
 	return currentCollectiveChecksum
 }
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
@@ -90,3 +90,28 @@ func TestShouldReturnEmptyFileHashWhenNoPathsPassed(t *testing.T) {
 	checksum := utility.CollectiveSHA256Hash([]string{})
 	assert.Equal(t, checksum, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "Should be equal to empty hash value when no paths passed")
 }
+
+func TestShouldNotScanPureRenameOfFileWithMatchingChecksum(t *testing.T) {
+	renamed := git_repo.NewAddition("renamed_file.pem", make([]byte, 0))
+	renamed.OldPath = "some_file.pem"
+	renamed.Similarity = 100
+	rc := NewTalismanRCIgnore([]byte(talismanRCWithCorrectChecksum))
+	cc := NewChecksumCompare([]git_repo.Addition{renamed}, rc)
+
+	assert.True(t, cc.IsScanNotRequired(renamed), "Should carry the checksum of some_file.pem over to its pure rename")
+}
+
+func TestShouldScanModifiedRenamesAndCopiesOfFileWithMatchingChecksum(t *testing.T) {
+	modified := git_repo.NewAddition("renamed_file.pem", make([]byte, 0))
+	modified.OldPath = "some_file.pem"
+	modified.Similarity = 90
+	copied := git_repo.NewAddition("copied_file.pem", make([]byte, 0))
+	copied.OldPath = "some_file.pem"
+	copied.Similarity = 100
+	copied.Copied = true
+	rc := NewTalismanRCIgnore([]byte(talismanRCWithCorrectChecksum))
+	cc := NewChecksumCompare([]git_repo.Addition{modified, copied}, rc)
+
+	assert.False(t, cc.IsScanNotRequired(modified), "Should scan a rename whose content changed")
+	assert.False(t, cc.IsScanNotRequired(copied), "Should scan a copy")
+}
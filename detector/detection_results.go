@@ -2,23 +2,32 @@ package detector
 
 import (
 	"fmt"
-	"os"
+	"sort"
 	"strings"
 	"talisman/git_repo"
 	"talisman/utility"
 
-	"github.com/olekukonko/tablewriter"
+	log "github.com/Sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
+//ErrorCategory is the category of the failures of files that could not be verified at all, e.g. because they could not be read
+const ErrorCategory = "error"
+
 type Details struct {
 	Category string `json:"type"`
 	Message string `json:"message"`
 	Commits []string `json:"commits"`
+	Secret *SecretPreview `json:"secret,omitempty"`
+	RuleID string `json:"rule_id,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	//Line is the number of the line of the file the secret was found on, when it could be located
+	Line int `json:"line,omitempty"`
 }
 
 type ResultsDetails struct {
 	Filename git_repo.FilePath `json:"filename"`
+	OldFilename git_repo.FilePath `json:"old_filename,omitempty"`
 	FailureList []Details      `json:"failure_list"`
 	WarningList []Details      `json:"warning_list"`
 	IgnoreList []Details 	   `json:"ignore_list"`
@@ -30,6 +39,9 @@ type FailureTypes struct  {
 	Filename int `json:"filename"`
 	Warnings int `json:"warnings"`
 	Ignores int `json:"ignores"`
+	Errors int `json:"errors"`
+	//Others counts the failures of detectors other than the built-in ones, e.g. plugins
+	Others int `json:"others"`
 }
 
 type ResultsSummary struct {
@@ -48,6 +60,11 @@ type ResultsSummary struct {
 type DetectionResults struct {
 	Summary ResultsSummary `json:"summary"`
 	Results []ResultsDetails `json:"results"`
+	redactionPolicy RedactionPolicy
+	logger *log.Logger
+	scanned map[git_repo.FilePath]bool
+	//secrets holds the matched secrets by fingerprint, to locate them in the files once all detectors ran. They are never reported.
+	secrets map[string]string
 }
 
 func (r *ResultsDetails) getWarningDataByCategoryAndMessage(failureMessage string, category string) *Details {
@@ -59,7 +76,7 @@ func (r *ResultsDetails) getWarningDataByCategoryAndMessage(failureMessage strin
 func (r *ResultsDetails) getFailureDataByCategoryAndMessage(failureMessage string, category string) *Details {
 	detail := getDetaisByCategoryAndMessage(r.FailureList, category, failureMessage)
 	if detail == nil {
-		detail = &Details{category, failureMessage, make([]string, 0)}
+		detail = &Details{Category: category, Message: failureMessage, Commits: make([]string, 0)}
 		r.FailureList = append(r.FailureList, *detail)
 	}
 	return detail
@@ -73,7 +90,7 @@ func (r *ResultsDetails) addIgnoreDataByCategory(category string) {
 		}
 	}
 	if !isCategoryAlreadyPresent {
-		detail := Details{category, "", make([]string, 0)}
+		detail := Details{Category: category, Commits: make([]string, 0)}
 		r.IgnoreList = append(r.IgnoreList, detail)
 	}
 }
@@ -101,34 +118,95 @@ func (r *DetectionResults) getResultDetailsForFilePath(fileName git_repo.FilePat
 
 //NewDetectionResults is a new DetectionResults struct. It represents the pre-run state of a Detection run.
 func NewDetectionResults() *DetectionResults {
-	result := DetectionResults{ResultsSummary{FailureTypes{0,0,0, 0, 0}},make([]ResultsDetails, 0)}
+	result := DetectionResults{Summary: ResultsSummary{FailureTypes{}}, Results: make([]ResultsDetails, 0)}
 	return &result
 }
 
+//SetRedactionPolicy sets the policy used to redact the secrets passed to FailWithSecret and WarnWithSecret
+func (r *DetectionResults) SetRedactionPolicy(policy RedactionPolicy) {
+	r.redactionPolicy = policy
+}
+
+
+//SetLogger sets the logger the detectors log their decisions to, instead of the standard logger
+func (r *DetectionResults) SetLogger(logger *log.Logger) {
+	r.logger = logger
+}
+
+//Logger returns the logger the detectors log their decisions to
+func (r *DetectionResults) Logger() *log.Logger {
+	if r.logger == nil {
+		return log.StandardLogger()
+	}
+	return r.logger
+}
+
+//RedactionPolicy returns the policy used to redact the secrets passed to FailWithSecret and WarnWithSecret
+func (r *DetectionResults) RedactionPolicy() RedactionPolicy {
+	return r.redactionPolicy
+}
 
 //Fail is used to mark the supplied FilePath as failing a detection for a supplied reason.
 //Detectors are encouraged to provide context sensitive messages so that fixing the errors is made simple for the end user
 //Fail may be called multiple times for each FilePath and the calls accumulate the provided reasons
 func (r *DetectionResults) Fail(filePath git_repo.FilePath, category string, message string, commits []string) {
+	r.addFailure(filePath, newDetails(filePath, category, category, message, message, commits))
+}
+
+//FailWithRule is used like Fail by detectors performing several checks, identifying the check that failed the file by its ruleID
+func (r *DetectionResults) FailWithRule(filePath git_repo.FilePath, category string, ruleID string, message string, commits []string) {
+	r.addFailure(filePath, newDetails(filePath, category, ruleID, message, message, commits))
+}
+
+//FailWithSecret is used like Fail when the reason for failing is a matched secret.
+//The message is expected to contain a single %s verb, which is filled in with a redacted preview of the secret, so that the secret itself never ends up in the results.
+//The ruleID identifies the check that matched the secret and, together with the secret, makes up the fingerprint of the failure.
+func (r *DetectionResults) FailWithSecret(filePath git_repo.FilePath, category string, ruleID string, message string, secret string, commits []string) {
+	r.addFailure(filePath, r.newSecretDetails(filePath, category, ruleID, message, secret, commits))
+}
+
+func newDetails(filePath git_repo.FilePath, category string, ruleID string, message string, matched string, commits []string) Details {
+	return Details{
+		Category:    category,
+		Message:     message,
+		Commits:     commits,
+		RuleID:      ruleID,
+		Fingerprint: Fingerprint(ruleID, filePath, matched),
+	}
+}
+
+func (r *DetectionResults) newSecretDetails(filePath git_repo.FilePath, category string, ruleID string, message string, secret string, commits []string) Details {
+	preview := r.redactionPolicy.Redact(secret)
+	detail := newDetails(filePath, category, ruleID, fmt.Sprintf(message, preview), secret, commits)
+	detail.Secret = preview
+	if r.secrets == nil {
+		r.secrets = make(map[string]string)
+	}
+	r.secrets[detail.Fingerprint] = secret
+	return detail
+}
+
+func (r *DetectionResults) addFailure(filePath git_repo.FilePath, failureDetails Details) {
+	category := failureDetails.Category
+	commits := failureDetails.Commits
 	isFilePresentInResults := false
 	for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
 		if r.Results[resultIndex].Filename == filePath {
 			isFilePresentInResults = true
-			isEntryPresentForGivenCategoryAndMessage := false
+			isEntryPresentForGivenFingerprint := false
 			for detailIndex := 0; detailIndex < len(r.Results[resultIndex].FailureList); detailIndex++ {
-				if strings.Compare(r.Results[resultIndex].FailureList[detailIndex].Category, category) == 0 && strings.Compare(r.Results[resultIndex].FailureList[detailIndex].Message, message) == 0 {
-					isEntryPresentForGivenCategoryAndMessage = true
+				if r.Results[resultIndex].FailureList[detailIndex].Fingerprint == failureDetails.Fingerprint {
+					isEntryPresentForGivenFingerprint = true
 					r.Results[resultIndex].FailureList[detailIndex].Commits = append(r.Results[resultIndex].FailureList[detailIndex].Commits, commits...)
 				}
 			}
-			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, Details{category, message, commits})
+			if !isEntryPresentForGivenFingerprint {
+				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, failureDetails)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		failureDetails := Details{category, message, commits}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		resultDetails := ResultsDetails{Filename: filePath, FailureList: make([]Details, 0), WarningList: make([]Details, 0), IgnoreList: make([]Details, 0)}
 		resultDetails.FailureList = append(resultDetails.FailureList, failureDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
@@ -136,31 +214,103 @@ func (r *DetectionResults) Fail(filePath git_repo.FilePath, category string, mes
 }
 
 func (r *DetectionResults) Warn(filePath git_repo.FilePath, category string, message string, commits []string) {
+	r.addWarning(filePath, newDetails(filePath, category, category, message, message, commits))
+}
+
+//WarnWithSecret is used like Warn when the reason for warning is a matched secret. See FailWithSecret.
+func (r *DetectionResults) WarnWithSecret(filePath git_repo.FilePath, category string, ruleID string, message string, secret string, commits []string) {
+	r.addWarning(filePath, r.newSecretDetails(filePath, category, ruleID, message, secret, commits))
+}
+
+func (r *DetectionResults) addWarning(filePath git_repo.FilePath, warningDetails Details) {
+	commits := warningDetails.Commits
 	isFilePresentInResults := false
 	for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
 		if r.Results[resultIndex].Filename == filePath {
 			isFilePresentInResults = true
-			isEntryPresentForGivenCategoryAndMessage := false
+			isEntryPresentForGivenFingerprint := false
 			for detailIndex := 0; detailIndex < len(r.Results[resultIndex].WarningList); detailIndex++ {
-				if strings.Compare(r.Results[resultIndex].WarningList[detailIndex].Category, category) == 0 && strings.Compare(r.Results[resultIndex].WarningList[detailIndex].Message, message) == 0 {
-					isEntryPresentForGivenCategoryAndMessage = true
+				if r.Results[resultIndex].WarningList[detailIndex].Fingerprint == warningDetails.Fingerprint {
+					isEntryPresentForGivenFingerprint = true
 					r.Results[resultIndex].WarningList[detailIndex].Commits = append(r.Results[resultIndex].WarningList[detailIndex].Commits, commits...)
 				}
 			}
-			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, Details{category, message, commits})
+			if !isEntryPresentForGivenFingerprint {
+				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, warningDetails)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		warningDetails := Details{category, message, commits}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		resultDetails := ResultsDetails{Filename: filePath, FailureList: make([]Details, 0), WarningList: make([]Details, 0), IgnoreList: make([]Details, 0)}
 		resultDetails.WarningList = append(resultDetails.WarningList, warningDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
 	r.Summary.Types.Warnings++
 }
 
+//Merge adds all the failures, warnings and ignores collected in other to the results
+func (r *DetectionResults) Merge(other *DetectionResults) {
+	for _, resultDetails := range other.Results {
+		for _, detail := range resultDetails.FailureList {
+			r.addFailure(resultDetails.Filename, detail)
+		}
+		for _, detail := range resultDetails.WarningList {
+			r.addWarning(resultDetails.Filename, detail)
+		}
+		for _, detail := range resultDetails.IgnoreList {
+			r.Ignore(resultDetails.Filename, detail.Category)
+		}
+	}
+}
+
+//recordRenames notes the path every renamed or copied addition with results came from, so that reports can show both paths
+func (r *DetectionResults) recordRenames(additions []git_repo.Addition) {
+	for _, addition := range additions {
+		if addition.OldPath == "" {
+			continue
+		}
+		for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
+			if r.Results[resultIndex].Filename == addition.Path {
+				r.Results[resultIndex].OldFilename = addition.OldPath
+			}
+		}
+	}
+}
+
+//Scanned records that the file was scanned, so that reports can list it even when nothing was detected in it
+func (r *DetectionResults) Scanned(filePath git_repo.FilePath) {
+	if r.scanned == nil {
+		r.scanned = make(map[git_repo.FilePath]bool)
+	}
+	r.scanned[filePath] = true
+}
+
+//ScannedFiles returns the files that were scanned or have results, in lexical order
+func (r *DetectionResults) ScannedFiles() []git_repo.FilePath {
+	files := make(map[git_repo.FilePath]bool)
+	for filePath := range r.scanned {
+		files[filePath] = true
+	}
+	for _, resultDetails := range r.Results {
+		files[resultDetails.Filename] = true
+	}
+	var result []git_repo.FilePath
+	for filePath := range files {
+		result = append(result, filePath)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+//displayName returns the path of a file as shown in reports, along with the path it was renamed or copied from
+func (r *DetectionResults) displayName(filePath git_repo.FilePath) string {
+	resultDetails := r.getResultDetailsForFilePath(filePath)
+	if resultDetails == nil || resultDetails.OldFilename == "" {
+		return string(filePath)
+	}
+	return fmt.Sprintf("%s (from %s)", filePath, resultDetails.OldFilename)
+}
+
 //Ignore is used to mark the supplied FilePath as being ignored.
 //The most common reason for this is that the FilePath is Denied by the Ignores supplied to the Detector, however, Detectors may use more sophisticated reasons to ignore files.
 func (r *DetectionResults) Ignore(filePath git_repo.FilePath, category string) {
@@ -177,14 +327,14 @@ func (r *DetectionResults) Ignore(filePath git_repo.FilePath, category string) {
 				}
 			}
 			if !isEntryPresentForGivenCategory {
-				detail := Details{category, "", make([]string, 0)}
+				detail := Details{Category: category, Commits: make([]string, 0)}
 				r.Results[resultIndex].IgnoreList = append(r.Results[resultIndex].IgnoreList, detail)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		ignoreDetails := Details{category, "", make([]string, 0)}
-		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+		ignoreDetails := Details{Category: category, Commits: make([]string, 0)}
+		resultDetails := ResultsDetails{Filename: filePath, FailureList: make([]Details, 0), WarningList: make([]Details, 0), IgnoreList: make([]Details, 0)}
 		resultDetails.IgnoreList = append(resultDetails.IgnoreList, ignoreDetails)
 		r.Results = append(r.Results, resultDetails)
 	}
@@ -193,8 +343,8 @@ func (r *DetectionResults) Ignore(filePath git_repo.FilePath, category string) {
 
 
 func createNewResultForFile(category string, message string, commits []string, filePath git_repo.FilePath) ResultsDetails {
-	failureDetails := Details{category, message, commits}
-	resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
+	failureDetails := Details{Category: category, Message: message, Commits: commits}
+	resultDetails := ResultsDetails{Filename: filePath, FailureList: make([]Details, 0), WarningList: make([]Details, 0), IgnoreList: make([]Details, 0)}
 	resultDetails.FailureList = append(resultDetails.FailureList, failureDetails)
 	return resultDetails
 }
@@ -206,13 +356,17 @@ func (r *DetectionResults) updateResultsSummary(category string) {
 		r.Summary.Types.Filename++
 	} else if strings.Compare("filesize", category) == 0 {
 		r.Summary.Types.Filesize++
+	} else if strings.Compare(ErrorCategory, category) == 0 {
+		r.Summary.Types.Errors++
+	} else {
+		r.Summary.Types.Others++
 	}
 
 }
 
 //HasFailures answers if any Failures were detected for any FilePath in the current run
 func (r *DetectionResults) HasFailures() bool {
-	return r.Summary.Types.Filesize > 0 || r.Summary.Types.Filename > 0 || r.Summary.Types.Filecontent > 0
+	return r.Summary.Types.Filesize > 0 || r.Summary.Types.Filename > 0 || r.Summary.Types.Filecontent > 0 || r.Summary.Types.Errors > 0 || r.Summary.Types.Others > 0
 }
 
 //HasIgnores answers if any FilePaths were ignored in the current run
@@ -238,103 +392,53 @@ func (r *DetectionResults) GetFailures(fileName git_repo.FilePath) []Details {
 	return r.getResultDetailsForFilePath(fileName).FailureList
 }
 
-func (r *DetectionResults) ReportWarnings() string {
-	var result string
-	var filePathsForWarnings []string
-	var data [][]string
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"File", "Warnings"})
-	table.SetRowLine(true)
-
-	for _, resultDetails := range r.Results {
-		if len(resultDetails.WarningList) > 0 {
-			filePathsForWarnings = append(filePathsForWarnings, string(resultDetails.Filename))
-			warningData := r.ReportFileWarnings(resultDetails.Filename)
-			data = append(data, warningData...)
-		}
+//TalismanRCSuggestion returns the .talismanrc entries for every file with failures or ignores, with the checksum of its current content,
+//or nothing when there are no failures. The ignored files are kept in it, so that pasting it over fileignoreconfig keeps ignoring them.
+func (r *DetectionResults) TalismanRCSuggestion() string {
+	if !r.HasFailures() {
+		return ""
 	}
-
-
-	filePathsForWarnings = utility.UniqueItems(filePathsForWarnings)
-	if r.Summary.Types.Warnings > 0 {
-		fmt.Printf("\n\x1b[1m\x1b[31mTalisman Warnings:\x1b[0m\x1b[0m\n")
-		table.AppendBulk(data)
-		table.Render()
-		result = result + fmt.Sprintf("\n\x1b[33mPlease review the above file(s) to make sure that no sensitive content is being pushed\x1b[0m\n")
-		result = result + fmt.Sprintf("\n")
-	}
-	return result
-}
-
-//Report returns a string documenting the various failures and ignored files for the current run
-func (r *DetectionResults) Report() string {
-	var result string
-	var filePathsForIgnoresAndFailures []string
-	var data [][]string
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"File", "Errors"})
-	table.SetRowLine(true)
-
+	var filePaths []string
 	for _, resultDetails := range r.Results {
 		if len(resultDetails.FailureList) > 0 || len(resultDetails.IgnoreList) > 0 {
-			filePathsForIgnoresAndFailures = append(filePathsForIgnoresAndFailures, string(resultDetails.Filename))
-			failureData := r.ReportFileFailures(resultDetails.Filename)
-			data = append(data, failureData...)
+			filePaths = append(filePaths, string(resultDetails.Filename))
 		}
 	}
-
-	filePathsForIgnoresAndFailures = utility.UniqueItems(filePathsForIgnoresAndFailures)
-
-	if r.HasFailures() {
-		fmt.Printf("\n\x1b[1m\x1b[31mTalisman Report:\x1b[0m\x1b[0m\n")
-		table.AppendBulk(data)
-		table.Render()
-		result = result + fmt.Sprintf("\n\x1b[33mIf you are absolutely sure that you want to ignore the above files from talisman detectors, consider pasting the following format in .talismanrc file in the project root\x1b[0m\n")
-		result = result + r.suggestTalismanRC(filePathsForIgnoresAndFailures)
-		result = result + fmt.Sprintf("\n\n")
-	}
-	return result
+	return r.suggestTalismanRC(utility.UniqueItems(filePaths))
 }
 
 func (r *DetectionResults) suggestTalismanRC(filePaths []string) string {
 	var fileIgnoreConfigs []FileIgnoreConfig
 	for _, filePath := range filePaths {
 		currentChecksum := utility.CollectiveSHA256Hash([]string{filePath})
-		fileIgnoreConfig := FileIgnoreConfig{filePath, currentChecksum, []string{}}
+		fileIgnoreConfig := FileIgnoreConfig{FileName: filePath, Checksum: currentChecksum, IgnoreDetectors: []string{}}
 		fileIgnoreConfigs = append(fileIgnoreConfigs, fileIgnoreConfig)
 	}
 
-	talismanRcIgnoreConfig := TalismanRCIgnore{fileIgnoreConfigs}
+	talismanRcIgnoreConfig := TalismanRCIgnore{FileIgnoreConfig: fileIgnoreConfigs}
 	m, _ := yaml.Marshal(&talismanRcIgnoreConfig)
 	return string(m)
 }
 
-//ReportFileFailures adds a string to table documenting the various failures detected on the supplied FilePath by all detectors in the current run
+//ReportFileFailures returns a table row of file, message and fingerprint for every failure detected on the supplied FilePath by all detectors in the current run
 func (r *DetectionResults) ReportFileFailures(filePath git_repo.FilePath) [][]string {
 	failureList := r.getResultDetailsForFilePath(filePath).FailureList
 	var data [][]string
 	if len(failureList) > 0 {
 		for _, detail := range failureList {
-			if len(detail.Message) > 150 {
-				detail.Message = detail.Message[:150] + "\n" + detail.Message[150:]
-			}
-			data = append(data, []string{string(filePath), detail.Message})
+			data = append(data, []string{r.displayName(filePath), detail.Message, detail.Fingerprint})
 		}
 	}
 	return data
 }
 
+//ReportFileWarnings returns a table row of file, message and fingerprint for every warning of the supplied FilePath, like ReportFileFailures
 func (r *DetectionResults) ReportFileWarnings(filePath git_repo.FilePath) [][]string {
 	warningList := r.getResultDetailsForFilePath(filePath).WarningList
 	var data [][]string
 	if len(warningList) > 0 {
 		for _, detail := range warningList {
-			if len(detail.Message) > 150 {
-				detail.Message = detail.Message[:150] + "\n" + detail.Message[150:]
-			}
-			data = append(data, []string{string(filePath), detail.Message})
+			data = append(data, []string{r.displayName(filePath), detail.Message, detail.Fingerprint})
 		}
 	}
 	return data
@@ -65,7 +65,7 @@ func TestTalismanRCSuggestionWhenThereAreFailures(t *testing.T) {
 	results := NewDetectionResults()
 	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
 
-	actualErrorReport := results.Report()
+	actualErrorReport := results.TalismanRCSuggestion()
 
 	assert.Regexp(t, "fileignoreconfig:", actualErrorReport, "Error report does not contain expected output")
 	assert.Regexp(t, "- filename: some_file.pem", actualErrorReport, "Error report does not contain expected output")
@@ -74,10 +74,22 @@ func TestTalismanRCSuggestionWhenThereAreFailures(t *testing.T) {
 
 }
 
+func TestTalismanRCSuggestionAlsoListsIgnoredFiles(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+	results.Ignore("test/some_file.pem", "filename")
+
+	actualErrorReport := results.TalismanRCSuggestion()
+
+	assert.Regexp(t, "- filename: some_file.pem", actualErrorReport, "Error report does not contain expected output")
+	assert.Regexp(t, "- filename: test/some_file.pem", actualErrorReport, "Error report should keep the ignored files")
+}
+
 func TestTalismanRCSuggestionWhenNoFailures(t *testing.T) {
 	results := NewDetectionResults()
+	results.Ignore("some_file.pem", "filename")
 
-	actualErrorReport := results.Report()
+	actualErrorReport := results.TalismanRCSuggestion()
 
 	assert.NotRegexp(t, "fileignoreconfig:", actualErrorReport, "Error report should not contain this output")
 
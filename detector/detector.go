@@ -1,10 +1,13 @@
 package detector
 
 import (
-	"os"
 	"talisman/git_repo"
 )
 
+//RulesVersion identifies the set of rules applied by the default detectors.
+//It must be changed whenever a detector or one of its rules changes, as it invalidates the findings cached by previous scans.
+const RulesVersion = "3"
+
 //Detector represents a single kind of test to be performed against a set of Additions
 //Detectors are expected to honor the ignores that are passed in and log them in the results
 //Detectors are expected to signal any errors to the results
@@ -27,13 +30,36 @@ func NewChain() *Chain {
 
 //DefaultChain returns a DetectorChain with pre-configured detectors
 func DefaultChain() *Chain {
+	return chainWith(NewFileContentDetector())
+}
+
+//AggressiveChain returns the DefaultChain with the file content detector in aggressive mode, which also flags shorter texts that decode as base64
+func AggressiveChain() *Chain {
+	return chainWith(NewFileContentDetector().AggressiveMode())
+}
+
+func chainWith(fileContentDetector *FileContentDetector) *Chain {
 	result := NewChain()
 	result.AddDetector(DefaultFileNameDetector())
-	result.AddDetector(NewFileContentDetector())
+	result.AddDetector(fileContentDetector)
 	result.AddDetector(NewPatternDetector())
+	result.AddDetector(NewBinaryFileDetector())
 	return result
 }
 
+//DefaultChainWithPlugins returns the DefaultChain extended with a detector for every plugin configured in .talismanrc
+func DefaultChainWithPlugins(ignoreConfig TalismanRCIgnore) *Chain {
+	return DefaultChain().AddPlugins(ignoreConfig)
+}
+
+//AddPlugins adds a detector for every plugin configured in .talismanrc to the chain
+func (dc *Chain) AddPlugins(ignoreConfig TalismanRCIgnore) *Chain {
+	for _, plugin := range PluginDetectors(ignoreConfig) {
+		dc.AddDetector(plugin)
+	}
+	return dc
+}
+
 //AddDetector adds the detector that is passed in to the chain
 func (dc *Chain) AddDetector(d Detector) *Chain {
 	dc.detectors = append(dc.detectors, d)
@@ -43,11 +69,12 @@ func (dc *Chain) AddDetector(d Detector) *Chain {
 //Test validates the additions against each detector in the chain.
 //The results are passed in from detector to detector and thus collect all errors from all detectors
 func (dc *Chain) Test(additions []git_repo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
-	wd, _ := os.Getwd()
-	repo := git_repo.RepoLocatedAt(wd)
-	gitTrackedFilesAsAdditions := repo.TrackedFilesAsAdditions()
-	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, additions...)
 	for _, v := range dc.detectors {
 		v.Test(additions, ignoreConfig, result)
 	}
+	result.recordRenames(additions)
+	result.recordLines(additions)
+	for _, addition := range additions {
+		result.Scanned(addition.Path)
+	}
 }
@@ -0,0 +1,114 @@
+package detector
+
+import (
+	"fmt"
+
+	"talisman/git_repo"
+)
+
+//EntropySettings tune the entropy based detection of the file content detector. Settings left out keep their defaults.
+type EntropySettings struct {
+	//Base64Threshold is the entropy above which base64 encoded texts are flagged, BASE64_ENTROPY_THRESHOLD by default
+	Base64Threshold *float64 `yaml:"base64_threshold,omitempty"`
+	//HexThreshold is the entropy above which hex encoded texts are flagged, HEX_ENTROPY_THRESHOLD by default
+	HexThreshold *float64 `yaml:"hex_threshold,omitempty"`
+	//MinBase64Length is the length below which base64 encoded texts are not flagged, MIN_BASE64_SECRET_LENGTH by default
+	MinBase64Length *int `yaml:"min_base64_length,omitempty"`
+	//MinHexLength is the length below which hex encoded texts are not flagged, MIN_HEX_SECRET_LENGTH by default
+	MinHexLength *int `yaml:"min_hex_length,omitempty"`
+	//AggressivenessThreshold is the length texts decoding as base64 must exceed to be flagged in aggressive mode, 15 by default
+	AggressivenessThreshold *int `yaml:"aggressiveness_threshold,omitempty"`
+}
+
+//EntropyFileConfig overrides the entropy settings for the files matching its pattern, e.g. to be stricter for *.env files
+type EntropyFileConfig struct {
+	Pattern         string `yaml:"pattern"`
+	EntropySettings `yaml:",inline"`
+}
+
+//EntropyConfig holds the entropy settings of the whole repository, and the overrides for files.
+//The overrides of every pattern matching a file apply in order, so later ones take precedence.
+type EntropyConfig struct {
+	EntropySettings `yaml:",inline"`
+	Files           []EntropyFileConfig `yaml:"files,omitempty"`
+}
+
+//SettingsFor returns the entropy settings that apply to the file of the addition
+func (c EntropyConfig) SettingsFor(addition git_repo.Addition) EntropySettings {
+	settings := c.EntropySettings
+	for _, file := range c.Files {
+		if file.Pattern != "" && addition.Matches(file.Pattern) {
+			settings = settings.overriddenBy(file.EntropySettings)
+		}
+	}
+	return settings
+}
+
+//Validate returns an error naming the first entropy setting, of the repository or of a file pattern, that is negative
+func (c EntropyConfig) Validate() error {
+	if err := c.EntropySettings.validate(); err != nil {
+		return err
+	}
+	for _, file := range c.Files {
+		if err := file.EntropySettings.validate(); err != nil {
+			return fmt.Errorf("%v for pattern %q", err, file.Pattern)
+		}
+	}
+	return nil
+}
+
+func (s EntropySettings) validate() error {
+	for name, value := range map[string]*float64{"base64_threshold": s.Base64Threshold, "hex_threshold": s.HexThreshold} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("invalid entropy setting %s: %v, expected a value of at least 0", name, *value)
+		}
+	}
+	for name, value := range map[string]*int{"min_base64_length": s.MinBase64Length, "min_hex_length": s.MinHexLength, "aggressiveness_threshold": s.AggressivenessThreshold} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("invalid entropy setting %s: %d, expected a length of at least 0", name, *value)
+		}
+	}
+	return nil
+}
+
+func (s EntropySettings) overriddenBy(other EntropySettings) EntropySettings {
+	if other.Base64Threshold != nil {
+		s.Base64Threshold = other.Base64Threshold
+	}
+	if other.HexThreshold != nil {
+		s.HexThreshold = other.HexThreshold
+	}
+	if other.MinBase64Length != nil {
+		s.MinBase64Length = other.MinBase64Length
+	}
+	if other.MinHexLength != nil {
+		s.MinHexLength = other.MinHexLength
+	}
+	if other.AggressivenessThreshold != nil {
+		s.AggressivenessThreshold = other.AggressivenessThreshold
+	}
+	return s
+}
+
+//tune returns a copy of the file content detector using the settings, leaving the detector itself untouched
+func (s EntropySettings) tune(fc *FileContentDetector) *FileContentDetector {
+	base64Detector := *fc.base64Detector
+	hexDetector := *fc.hexDetector
+	tuned := &FileContentDetector{base64Detector: &base64Detector, hexDetector: &hexDetector, creditCardDetector: fc.creditCardDetector}
+	if s.Base64Threshold != nil {
+		base64Detector.threshold = s.Base64Threshold
+	}
+	if s.MinBase64Length != nil {
+		base64Detector.minLength = s.MinBase64Length
+	}
+	if s.HexThreshold != nil {
+		hexDetector.threshold = s.HexThreshold
+	}
+	if s.MinHexLength != nil {
+		hexDetector.minLength = s.MinHexLength
+	}
+	if s.AggressivenessThreshold != nil && base64Detector.aggressiveDetector != nil {
+		base64Detector.aggressiveDetector = &Base64AggressiveDetector{threshold: *s.AggressivenessThreshold}
+	}
+	return tuned
+}
@@ -0,0 +1,133 @@
+package detector
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"talisman/git_repo"
+)
+
+//ExclusionsVersion identifies the set of built-in exclusions. It must be changed whenever an exclusion is added, removed or changed.
+const ExclusionsVersion = "2"
+
+//AllExclusions disables every built-in exclusion when listed in ExclusionsConfig.Disabled
+const AllExclusions = "all"
+
+//exclusion spares the file content detector the high entropy texts of a kind of file known to hold no secrets,
+//either by skipping the files entirely or by removing the values known to be safe from them.
+//The pattern detector still scans the files, as they may hold secrets it recognises, e.g. credentials in the URLs of lockfiles.
+type exclusion struct {
+	description string
+	//patterns select the files the exclusion applies to by name, all files if there are none
+	patterns []string
+	skip     bool
+	safe     *regexp.Regexp
+}
+
+var builtInExclusions = map[string]exclusion{
+	"lockfiles": {
+		description: "integrity hashes and checksums of dependency lockfiles, their resolved URLs are still scanned as they may hold credentials",
+		patterns:    []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "composer.lock", "poetry.lock"},
+		safe:        regexp.MustCompile(`(?m)"?\b(?:integrity|shasum|checksum|hash|reference)"?[ \t]*[:=]?[ \t]*(?:"(?:sha(?:1|256|384|512)[-:])?[A-Za-z0-9+/]+=*"|'(?:sha(?:1|256|384|512)[-:])?[A-Za-z0-9+/]+=*'|(?:sha(?:1|256|384|512)[-:])?[A-Za-z0-9+/]+=*(?:[ \t,}]|$))`),
+	},
+	"go-sum": {
+		description: "module hashes of go.sum files",
+		patterns:    []string{"go.sum", "go.work.sum"},
+		safe:        regexp.MustCompile(`(?m)[ \t]h1:[A-Za-z0-9+/]+=*[ \t]*$`),
+	},
+	"sri-hashes": {
+		description: "subresource integrity hashes, e.g. integrity=\"sha384-...\" in HTML",
+		safe:        regexp.MustCompile(`integrity[ \t]*=[ \t]*["']?sha(?:256|384|512)-[A-Za-z0-9+/]+=*["']?`),
+	},
+	"minified": {
+		description: "minified JavaScript and CSS",
+		patterns:    []string{"*.min.js", "*.min.mjs", "*.min.css"},
+		skip:        true,
+	},
+	"source-maps": {
+		description: "source maps of JavaScript and CSS",
+		patterns:    []string{"*.js.map", "*.mjs.map", "*.css.map"},
+		skip:        true,
+	},
+}
+
+//ExclusionsConfig configures the built-in exclusions, all of which are enabled by default
+type ExclusionsConfig struct {
+	//Disabled lists the names of the built-in exclusions not to apply, or AllExclusions
+	Disabled []string `yaml:"disabled,omitempty"`
+}
+
+//ExclusionNames returns the sorted names of the built-in exclusions
+func ExclusionNames() []string {
+	var names []string
+	for name := range builtInExclusions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Validate returns an error naming the first disabled exclusion that is not a built-in one
+func (c ExclusionsConfig) Validate() error {
+	for _, name := range c.Disabled {
+		if _, ok := builtInExclusions[name]; !ok && name != AllExclusions {
+			return fmt.Errorf("unknown exclusion %q, expected %s or one of %v", name, AllExclusions, ExclusionNames())
+		}
+	}
+	return nil
+}
+
+func (c ExclusionsConfig) enabled(addition git_repo.Addition) map[string]exclusion {
+	result := map[string]exclusion{}
+	if contains(c.Disabled, AllExclusions) {
+		return result
+	}
+	for name, e := range builtInExclusions {
+		if !contains(c.Disabled, name) && e.appliesTo(addition) {
+			result[name] = e
+		}
+	}
+	return result
+}
+
+func (e exclusion) appliesTo(addition git_repo.Addition) bool {
+	if len(e.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range e.patterns {
+		if matched, _ := path.Match(pattern, string(addition.Name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//ignoreIfSkipped records the file of the addition as ignored for the category when a built-in exclusion skips it, and answers whether it did
+func (c ExclusionsConfig) ignoreIfSkipped(addition git_repo.Addition, category string, result *DetectionResults) bool {
+	for name, e := range c.enabled(addition) {
+		if e.skip {
+			result.Logger().WithFields(log.Fields{
+				"filePath":  addition.Path,
+				"exclusion": name,
+			}).Info("Ignoring addition as it is skipped by a built-in exclusion.")
+			result.Ignore(addition.Path, category)
+			return true
+		}
+	}
+	return false
+}
+
+//withoutSafeValues returns the data of the addition without the values the built-in exclusions know to be safe.
+//Lines are kept, so that findings are still reported on the lines they are in.
+func (c ExclusionsConfig) withoutSafeValues(addition git_repo.Addition) []byte {
+	data := addition.Data
+	for _, e := range c.enabled(addition) {
+		if e.safe != nil {
+			data = e.safe.ReplaceAll(data, nil)
+		}
+	}
+	return data
+}
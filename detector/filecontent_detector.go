@@ -1,7 +1,6 @@
 package detector
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 
@@ -27,7 +26,7 @@ func NewFileContentDetector() *FileContentDetector {
 }
 
 func (fc *FileContentDetector) AggressiveMode() *FileContentDetector {
-	fc.base64Detector.aggressiveDetector = &Base64AggressiveDetector{}
+	fc.base64Detector.aggressiveDetector = &Base64AggressiveDetector{threshold: aggressivenessThreshold}
 	return fc
 }
 
@@ -35,12 +34,20 @@ func (fc *FileContentDetector) Test(additions []git_repo.Addition, ignoreConfig
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
 		if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition) {
-			log.WithFields(log.Fields{
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
 			result.Ignore(addition.Path, "filecontent")
 			continue
 		}
+		if ignoreConfig.Exclusions.ignoreIfSkipped(addition, "filecontent", result) {
+			continue
+		}
+		addition, scan := ignoreConfig.Binary.contentToScan(addition, "filecontent", result)
+		if !scan {
+			continue
+		}
+		addition.Data = ignoreConfig.Exclusions.withoutSafeValues(addition)
 
 		if string(addition.Name) == DefaultRCFileName {
 			re := regexp.MustCompile(`(?i)checksum[ \t]*:[ \t]*[0-9a-fA-F]+`)
@@ -49,48 +56,50 @@ func (fc *FileContentDetector) Test(additions []git_repo.Addition, ignoreConfig
 			addition.Data = data
 		}
 
-		base64Results := fc.detectFile(addition.Data, checkBase64)
-		fillBase46DetectionResults(base64Results, addition, result)
+		tuned := ignoreConfig.Entropy.SettingsFor(addition).tune(fc)
+		tuned.base64Detector.wordCheck = &WordCheck{logger: result.Logger()}
+		base64Results := tuned.detectFile(addition.Data, checkBase64)
+		fillBase46DetectionResults(base64Results, addition, ignoreConfig.Allowlist, result)
 
-		hexResults := fc.detectFile(addition.Data, checkHex)
-		fillHexDetectionResults(hexResults, addition, result)
+		hexResults := tuned.detectFile(addition.Data, checkHex)
+		fillHexDetectionResults(hexResults, addition, ignoreConfig.Allowlist, result)
 
-		creditCardResults := fc.detectFile(addition.Data, checkCreditCardNumber)
-		fillCreditCardDetectionResults(creditCardResults, addition, result)
+		creditCardResults := tuned.detectFile(addition.Data, checkCreditCardNumber)
+		fillCreditCardDetectionResults(creditCardResults, addition, ignoreConfig.Allowlist, result)
 	}
 }
 
-func fillResults(results []string, addition git_repo.Addition, result *DetectionResults, info string, output string) {
+func fillResults(results []string, addition git_repo.Addition, allowlist Allowlist, result *DetectionResults, ruleID string, info string, output string) {
 	for _, res := range results {
-		if res != "" {
-			log.WithFields(log.Fields{
+		if res != "" && !allowlist.ignoreIfAllowed(res, addition.Path, "filecontent", result) {
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info(info)
 			if string(addition.Name) == DefaultRCFileName {
-				result.Warn(addition.Path, "filecontent", fmt.Sprintf(output, res), []string{})
+				result.WarnWithSecret(addition.Path, "filecontent", ruleID, output, res, addition.Commits)
 			} else {
-				result.Fail(addition.Path, "filecontent", fmt.Sprintf(output, res), []string{})
+				result.FailWithSecret(addition.Path, "filecontent", ruleID, output, res, addition.Commits)
 			}
 		}
 	}
 }
 
-func fillBase46DetectionResults(base64Results []string, addition git_repo.Addition, result *DetectionResults) {
+func fillBase46DetectionResults(base64Results []string, addition git_repo.Addition, allowlist Allowlist, result *DetectionResults) {
 	const info = "Failing file as it contains a base64 encoded text."
 	const output = "Expected file to not to contain base64 encoded texts such as: %s"
-	fillResults(base64Results, addition, result, info, output)
+	fillResults(base64Results, addition, allowlist, result, Base64RuleID, info, output)
 }
 
-func fillCreditCardDetectionResults(creditCardResults []string, addition git_repo.Addition, result *DetectionResults) {
+func fillCreditCardDetectionResults(creditCardResults []string, addition git_repo.Addition, allowlist Allowlist, result *DetectionResults) {
 	const info = "Failing file as it contains a potential credit card number."
 	const output = "Expected file to not to contain credit card numbers such as: %s"
-	fillResults(creditCardResults, addition, result, info, output)
+	fillResults(creditCardResults, addition, allowlist, result, CreditCardRuleID, info, output)
 }
 
-func fillHexDetectionResults(hexResults []string, addition git_repo.Addition, result *DetectionResults) {
+func fillHexDetectionResults(hexResults []string, addition git_repo.Addition, allowlist Allowlist, result *DetectionResults) {
 	const info = "Failing file as it contains a hex encoded text."
 	const output = "Expected file to not to contain hex encoded texts such as: %s"
-	fillResults(hexResults, addition, result, info, output)
+	fillResults(hexResults, addition, allowlist, result, HexRuleID, info, output)
 }
 
 func (fc *FileContentDetector) detectFile(data []byte, getResult fn) []string {
@@ -102,6 +111,9 @@ func (fc *FileContentDetector) checkEachLine(content string, getResult fn) []str
 	lines := strings.Split(content, "\n")
 	res := []string{}
 	for _, line := range lines {
+		if IsSuppressedLine(line) {
+			continue
+		}
 		lineResult := fc.checkEachWord(line, getResult)
 		if len(lineResult) > 0 {
 			res = append(res, lineResult...)
@@ -123,7 +123,7 @@ func TestShouldFlagPotentialSecretsEncodedInHex(t *testing.T) {
 	filePath := additions[0].Path
 
 	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
-	expectedMessage := "Expected file to not to contain hex encoded texts such as: " + hex
+	expectedMessage := "Expected file to not to contain hex encoded texts such as: " + redacted(hex)
 	assert.Equal(t, expectedMessage, getFailureMessages(results, filePath)[0])
 }
 
@@ -138,9 +138,10 @@ func TestResultsShouldContainHexTextsIfHexAndBase64ExistInFile(t *testing.T) {
 	filePath := additions[0].Path
 
 	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
-	expectedMessage := "Expected file to not to contain hex encoded texts such as: " + hex
+	expectedMessage := "Expected file to not to contain hex encoded texts such as: " + redacted(hex)
 	messageReceived := strings.Join(getFailureMessages(results, filePath), " ")
-	assert.Regexp(t, expectedMessage, messageReceived, "Should contain hex detection message")
+	assert.Contains(t, messageReceived, expectedMessage, "Should contain hex detection message")
+	assert.NotContains(t, messageReceived, hex, "Should not contain the hex encoded text itself")
 }
 
 func TestResultsShouldContainBase64TextsIfHexAndBase64ExistInFile(t *testing.T) {
@@ -154,9 +155,10 @@ func TestResultsShouldContainBase64TextsIfHexAndBase64ExistInFile(t *testing.T)
 	filePath := additions[0].Path
 
 	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
-	expectedMessage := "Expected file to not to contain base64 encoded texts such as: " + base64
+	expectedMessage := "Expected file to not to contain base64 encoded texts such as: " + redacted(base64)
 	messageReceived := strings.Join(getFailureMessages(results, filePath), " ")
-	assert.Regexp(t, expectedMessage, messageReceived, "Should contain base64 detection message")
+	assert.Contains(t, messageReceived, expectedMessage, "Should contain base64 detection message")
+	assert.NotContains(t, messageReceived, base64, "Should not contain the base64 encoded text itself")
 }
 
 func TestResultsShouldContainCreditCardNumberIfCreditCardNumberExistInFile(t *testing.T) {
@@ -168,10 +170,24 @@ func TestResultsShouldContainCreditCardNumberIfCreditCardNumberExistInFile(t *te
 	filePath := additions[0].Path
 
 	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
-	expectedMessage := "Expected file to not to contain credit card numbers such as: " + creditCardNumber
+	expectedMessage := "Expected file to not to contain credit card numbers such as: " + redacted(creditCardNumber)
 	assert.Equal(t, expectedMessage, getFailureMessages(results, filePath)[0])
 }
 
+func TestResultsShouldContainSecretsWhenRedactionIsTurnedOff(t *testing.T) {
+	const hex string = "68656C6C6F20776F726C6421"
+	results := NewDetectionResults()
+	results.SetRedactionPolicy(RedactionPolicy{ShowSecrets: true})
+	additions := []git_repo.Addition{git_repo.NewAddition("filename", []byte(hex))}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+	assert.Contains(t, getFailureMessages(results, additions[0].Path)[0], hex)
+}
+
+func redacted(secret string) string {
+	return RedactionPolicy{}.Redact(secret).String()
+}
+
 func getFailureMessages(results *DetectionResults, filePath git_repo.FilePath) []string {
 	failureMessages := []string{}
 	for _, failureDetails := range results.GetFailures(filePath) {
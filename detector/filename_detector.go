@@ -80,7 +80,7 @@ func (fd FileNameDetector) Test(additions []git_repo.Addition, ignoreConfig Tali
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
 		if ignoreConfig.Deny(addition, "filename") || cc.IsScanNotRequired(addition){
-			log.WithFields(log.Fields{
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
 			result.Ignore(addition.Path, "filename")
@@ -88,7 +88,7 @@ func (fd FileNameDetector) Test(additions []git_repo.Addition, ignoreConfig Tali
 		}
 		for _, pattern := range fd.flagPatterns {
 			if pattern.MatchString(string(addition.Name)) {
-				log.WithFields(log.Fields{
+				result.Logger().WithFields(log.Fields{
 					"filePath": addition.Path,
 					"pattern":  pattern,
 				}).Info("Failing file as it matched pattern.")
@@ -24,15 +24,18 @@ func (fd FileSizeDetector) Test(additions []git_repo.Addition, ignoreConfig Tali
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
 		if ignoreConfig.Deny(addition, "filesize") || cc.IsScanNotRequired(addition) {
-			log.WithFields(log.Fields{
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
 			result.Ignore(addition.Path, "filesize")
 			continue
 		}
-		size := len(addition.Data)
+		size := addition.Size
+		if size == 0 {
+			size = len(addition.Data)
+		}
 		if size > fd.size {
-			log.WithFields(log.Fields{
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 				"fileSize": size,
 				"maxSize":  fd.size,
@@ -0,0 +1,61 @@
+package detector
+
+import (
+	"strings"
+
+	"talisman/git_repo"
+)
+
+//recordLines notes the line every secret found in the additions was found on, so that reports can point to it
+func (r *DetectionResults) recordLines(additions []git_repo.Addition) {
+	for _, addition := range additions {
+		for resultIndex := 0; resultIndex < len(r.Results); resultIndex++ {
+			if r.Results[resultIndex].Filename != addition.Path {
+				continue
+			}
+			r.locateSecrets(addition, r.Results[resultIndex].FailureList)
+			r.locateSecrets(addition, r.Results[resultIndex].WarningList)
+		}
+	}
+}
+
+func (r *DetectionResults) locateSecrets(addition git_repo.Addition, detailsList []Details) {
+	for detailIndex := range detailsList {
+		secret, ok := r.secrets[detailsList[detailIndex].Fingerprint]
+		if ok && detailsList[detailIndex].Line == 0 {
+			detailsList[detailIndex].Line = lineOf(addition, secret)
+		}
+	}
+}
+
+//lineOf returns the number of the first unsuppressed line of the new version of the file the text starts on, or 0 if it cannot be found.
+//When only the changes to the file were read, the text is searched on the added lines of the changed sections, which know where they start in the file.
+func lineOf(addition git_repo.Addition, text string) int {
+	if len(addition.Hunks) == 0 {
+		return 1 + lineIndexIn(string(addition.Data), text, func(int) bool { return true })
+	}
+	for _, hunk := range addition.Hunks {
+		if lineIndex := lineIndexIn(hunk.Text(), text, func(i int) bool { return hunk.Added[i] }); lineIndex != -1 {
+			return hunk.StartLine + lineIndex
+		}
+	}
+	return 0
+}
+
+//lineIndexIn returns the index of the first accepted, unsuppressed line of the content the text starts on, or -1 if there is none
+func lineIndexIn(content string, text string, accept func(lineIndex int) bool) int {
+	lines := strings.Split(content, "\n")
+	for offset := 0; text != ""; {
+		index := strings.Index(content[offset:], text)
+		if index == -1 {
+			return -1
+		}
+		start := offset + index
+		lineIndex := strings.Count(content[:start], "\n")
+		if accept(lineIndex) && !IsSuppressedLine(lines[lineIndex]) {
+			return lineIndex
+		}
+		offset = start + len(text)
+	}
+	return -1
+}
@@ -0,0 +1,30 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"talisman/git_repo"
+)
+
+const fingerprintLength = 16
+
+//Rule IDs identify the individual checks performed by the detectors. They are part of the fingerprint of every finding and thus must never change.
+const (
+	Base64RuleID        string = "filecontent-base64"
+	HexRuleID           string = "filecontent-hex"
+	CreditCardRuleID    string = "filecontent-creditcard"
+	SecretPatternRuleID string = "filecontent-pattern"
+	ExecutableRuleID    string = "binaryfile-executable"
+	BinaryPolicyRuleID  string = "binaryfile-policy"
+)
+
+//Fingerprint returns a deterministic identifier for a finding, derived from the rule that produced it, the path it was found in and a hash of the matched content.
+//The same finding gets the same fingerprint across runs, commits and machines, which lets downstream tooling track individual findings over time.
+func Fingerprint(ruleID string, filePath git_repo.FilePath, matched string) string {
+	matchedHash := sha256.Sum256([]byte(matched))
+	key := strings.Join([]string{ruleID, string(filePath), hex.EncodeToString(matchedHash[:])}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
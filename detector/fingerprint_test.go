@@ -0,0 +1,47 @@
+package detector
+
+import (
+	"testing"
+
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintIsDeterministic(t *testing.T) {
+	assert.Equal(t, Fingerprint(HexRuleID, "some/file", "secret"), Fingerprint(HexRuleID, "some/file", "secret"))
+	assert.Len(t, Fingerprint(HexRuleID, "some/file", "secret"), 16)
+}
+
+func TestFingerprintDependsOnRulePathAndMatchedContent(t *testing.T) {
+	fingerprint := Fingerprint(HexRuleID, "some/file", "secret")
+	assert.NotEqual(t, fingerprint, Fingerprint(Base64RuleID, "some/file", "secret"))
+	assert.NotEqual(t, fingerprint, Fingerprint(HexRuleID, "some/other_file", "secret"))
+	assert.NotEqual(t, fingerprint, Fingerprint(HexRuleID, "some/file", "another secret"))
+}
+
+func TestFingerprintIsIndependentOfRedaction(t *testing.T) {
+	const hex string = "68656C6C6F20776F726C6421"
+	additions := []git_repo.Addition{git_repo.NewAddition("filename", []byte(hex))}
+	redactedResults := NewDetectionResults()
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, redactedResults)
+	shownResults := NewDetectionResults()
+	shownResults.SetRedactionPolicy(RedactionPolicy{ShowSecrets: true})
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, shownResults)
+
+	expected := Fingerprint(HexRuleID, "filename", hex)
+	assert.Equal(t, expected, redactedResults.GetFailures("filename")[0].Fingerprint)
+	assert.Equal(t, expected, shownResults.GetFailures("filename")[0].Fingerprint)
+	assert.Equal(t, HexRuleID, redactedResults.GetFailures("filename")[0].RuleID)
+}
+
+func TestSameFindingReportedTwiceIsRecordedOnceWithAllCommits(t *testing.T) {
+	results := NewDetectionResults()
+	results.FailWithSecret("filename", "filecontent", HexRuleID, "contains %s", "secret", []string{"commit1"})
+	results.FailWithSecret("filename", "filecontent", HexRuleID, "contains %s", "secret", []string{"commit2"})
+	results.FailWithSecret("filename", "filecontent", HexRuleID, "contains %s", "another secret", []string{"commit2"})
+
+	failures := results.GetFailures("filename")
+	assert.Len(t, failures, 2)
+	assert.Equal(t, []string{"commit1", "commit2"}, failures[0].Commits)
+}
@@ -7,6 +7,9 @@ const MIN_HEX_SECRET_LENGTH = 20
 type HexDetector struct {
 	hexMap map[string]bool
 	entropy *Entropy
+	//threshold and minLength override HEX_ENTROPY_THRESHOLD and MIN_HEX_SECRET_LENGTH when set
+	threshold *float64
+	minLength *int
 }
 
 func NewHexDetector() *HexDetector {
@@ -24,12 +27,26 @@ func (hd *HexDetector) initHexMap() {
 }
 
 func (hd *HexDetector) checkHexEncoding(word string) string {
-	entropyCandidates := hd.entropy.GetEntropyCandidatesWithinWord(word, MIN_HEX_SECRET_LENGTH, hd.hexMap)
+	entropyCandidates := hd.entropy.GetEntropyCandidatesWithinWord(word, hd.minimumLength(), hd.hexMap)
 	for _, candidate := range entropyCandidates {
 		entropy := hd.entropy.GetShannonEntropy(candidate, HEX_CHARS)
-		if entropy > HEX_ENTROPY_THRESHOLD {
+		if entropy > hd.entropyThreshold() {
 			return word
 		}
 	}
 	return ""
-}
\ No newline at end of file
+}
+
+func (hd *HexDetector) entropyThreshold() float64 {
+	if hd.threshold == nil {
+		return HEX_ENTROPY_THRESHOLD
+	}
+	return *hd.threshold
+}
+
+func (hd *HexDetector) minimumLength() int {
+	if hd.minLength == nil {
+		return MIN_HEX_SECRET_LENGTH
+	}
+	return *hd.minLength
+}
@@ -1,13 +1,15 @@
 package detector
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v2"
-	"log"
 	"reflect"
 	"regexp"
 	"strings"
 
 	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
 )
 
 const (
@@ -37,39 +39,93 @@ type Ignore struct {
 }
 
 type FileIgnoreConfig struct {
-	FileName        string `yaml:"filename"`
-	Checksum        string `yaml:"checksum"`
+	FileName        string   `yaml:"filename"`
+	Checksum        string   `yaml:"checksum"`
 	IgnoreDetectors []string `yaml:"ignore_detectors"`
+	Reason          string   `yaml:"reason,omitempty"`
 }
 
 type TalismanRCIgnore struct {
 	FileIgnoreConfig []FileIgnoreConfig  `yaml:"fileignoreconfig"`
+	Redaction        RedactionPolicy     `yaml:"redaction,omitempty"`
+	Plugins          []PluginConfig      `yaml:"plugins,omitempty"`
+	Allowlist        Allowlist           `yaml:"allowlist,omitempty"`
+	Entropy          EntropyConfig       `yaml:"entropy,omitempty"`
+	Exclusions       ExclusionsConfig    `yaml:"exclusions,omitempty"`
+	Binary           BinaryConfig        `yaml:"binary,omitempty"`
 }
 
 func (ignore TalismanRCIgnore) IsEmpty() bool {
 	return reflect.DeepEqual(TalismanRCIgnore{}, ignore)
 }
 
-func ReadConfigFromRCFile(repoFileRead func(string) ([]byte, error)) TalismanRCIgnore {
+//AddFileIgnoreConfig adds the supplied config, replacing any existing config for the same filename
+func (ignore *TalismanRCIgnore) AddFileIgnoreConfig(config FileIgnoreConfig) {
+	for i, existing := range ignore.FileIgnoreConfig {
+		if existing.FileName == config.FileName {
+			ignore.FileIgnoreConfig[i] = config
+			return
+		}
+	}
+	ignore.FileIgnoreConfig = append(ignore.FileIgnoreConfig, config)
+}
+
+func ReadConfigFromRCFile(repoFileRead func(string) ([]byte, error), logger *log.Logger) TalismanRCIgnore {
 	fileContents, error := repoFileRead(DefaultRCFileName)
 	if error != nil {
 		panic(error)
 	}
-	return NewTalismanRCIgnore(fileContents)
+	return NewTalismanRCIgnoreWithLogger(fileContents, logger)
 }
 
 
 func NewTalismanRCIgnore(fileContents []byte) (TalismanRCIgnore) {
-	talismanRCIgnore := TalismanRCIgnore{}
-	err := yaml.Unmarshal([]byte(fileContents), &talismanRCIgnore)
+	return NewTalismanRCIgnoreWithLogger(fileContents, log.StandardLogger())
+}
+
+//NewTalismanRCIgnoreWithLogger parses the contents of a .talismanrc file like NewTalismanRCIgnore, logging a parse error to the logger
+func NewTalismanRCIgnoreWithLogger(fileContents []byte, logger *log.Logger) (TalismanRCIgnore) {
+	talismanRCIgnore, err := ParseTalismanRCIgnore(fileContents)
 	if err != nil {
-		log.Println("Unable to parse .talismanrc")
-		log.Printf("error: %v", err)
-		return talismanRCIgnore
+		logger.Errorf("Unable to parse .talismanrc: %v", err)
 	}
 	return talismanRCIgnore
 }
 
+//ParseTalismanRCIgnore parses the contents of a .talismanrc file, returning the parse error rather than logging it.
+//Every invalid section is dropped, so that none of its values is applied, and named in the error.
+func ParseTalismanRCIgnore(fileContents []byte) (TalismanRCIgnore, error) {
+	talismanRCIgnore := TalismanRCIgnore{}
+	if err := yaml.Unmarshal(fileContents, &talismanRCIgnore); err != nil {
+		return talismanRCIgnore, err
+	}
+	var invalid []string
+	if err := talismanRCIgnore.Redaction.Validate(); err != nil {
+		talismanRCIgnore.Redaction = RedactionPolicy{}
+		invalid = append(invalid, err.Error())
+	}
+	if err := talismanRCIgnore.Allowlist.Validate(); err != nil {
+		talismanRCIgnore.Allowlist = Allowlist{}
+		invalid = append(invalid, err.Error())
+	}
+	if err := talismanRCIgnore.Entropy.Validate(); err != nil {
+		talismanRCIgnore.Entropy = EntropyConfig{}
+		invalid = append(invalid, err.Error())
+	}
+	if err := talismanRCIgnore.Exclusions.Validate(); err != nil {
+		talismanRCIgnore.Exclusions = ExclusionsConfig{}
+		invalid = append(invalid, err.Error())
+	}
+	if err := talismanRCIgnore.Binary.Validate(); err != nil {
+		talismanRCIgnore.Binary = BinaryConfig{}
+		invalid = append(invalid, err.Error())
+	}
+	if len(invalid) > 0 {
+		return talismanRCIgnore, fmt.Errorf("ignoring the invalid sections: %s", strings.Join(invalid, "; "))
+	}
+	return talismanRCIgnore, nil
+}
+
 //ReadIgnoresFromFile builds an Ignores from the lines configured in a File.
 //The file itself is supplied as a File Read operation, which is specified, by default, as reading a file in the root of the repository.
 //The file name that is read is DEFAULT_IGNORE_FILE_NAME (".talismanignore")
@@ -126,11 +182,15 @@ func (i TalismanRCIgnore) Accept(addition git_repo.Addition, detectorName string
 	return !i.Deny(addition, detectorName)
 }
 
-//Deny answers true if the Addition.Path is configured to be ignored and not checked by the detectors
+//Deny answers true if the Addition.Path is configured to be ignored and not checked by the detectors.
+//A file that was renamed without any change to its content is also ignored if the path it was renamed from is.
 func (i TalismanRCIgnore) Deny(addition git_repo.Addition, detectorName string) bool {
 	result := false
 	for _, pattern := range i.effectiveRules(detectorName) {
 		result = result || addition.Matches(pattern)
+		if addition.IsPureRename() {
+			result = result || git_repo.NewAddition(string(addition.OldPath), addition.Data).Matches(pattern)
+		}
 	}
 	return result
 }
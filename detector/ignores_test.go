@@ -1,10 +1,12 @@
 package detector
 
 import (
+	"bytes"
 	"testing"
 
 	"talisman/git_repo"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,6 +112,16 @@ func testAddition(path string) git_repo.Addition {
 	return git_repo.NewAddition(path, make([]byte, 0))
 }
 
+func TestShouldLogParseErrorsOfTalismanRCToTheLogger(t *testing.T) {
+	var output bytes.Buffer
+	logger := log.New()
+	logger.Out = &output
+
+	NewTalismanRCIgnoreWithLogger([]byte("fileignoreconfig: not a list"), logger)
+
+	assert.Contains(t, output.String(), "Unable to parse .talismanrc")
+}
+
 func CreateTalismanRCIgnoreWithFileName(filename string, detector string) TalismanRCIgnore {
 	fileIgnoreConfig := FileIgnoreConfig{}
 	fileIgnoreConfig.FileName = filename
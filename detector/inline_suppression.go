@@ -0,0 +1,89 @@
+package detector
+
+import (
+	"path/filepath"
+	"strings"
+
+	"talisman/git_repo"
+)
+
+//InlineSuppressionMarker is the marker that, when it ends a line as a trailing comment, stops content detectors from flagging that line
+const InlineSuppressionMarker = "talisman:ignore"
+
+var suppressionCommentPrefixes = []string{"//", "--", "#"}
+
+//IsSuppressedLine states whether the line ends in a comment holding only the InlineSuppressionMarker, as written by SuppressLine.
+//The marker anywhere else on the line, e.g. inside a string literal, does not suppress it.
+func IsSuppressedLine(line string) bool {
+	line = strings.TrimRight(line, " \t\r")
+	for _, prefix := range suppressionCommentPrefixes {
+		if strings.HasSuffix(line, " "+prefix+" "+InlineSuppressionMarker) || line == prefix+" "+InlineSuppressionMarker {
+			return true
+		}
+	}
+	return false
+}
+
+//withoutSuppressedLines blanks out every suppressed line while keeping line numbers intact
+func withoutSuppressedLines(content string) string {
+	if !strings.Contains(content, InlineSuppressionMarker) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if IsSuppressedLine(line) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//withoutSuppressedHunkLines blanks out every suppressed line of the hunk
+func withoutSuppressedHunkLines(hunk git_repo.Hunk) git_repo.Hunk {
+	lines := make([]string, len(hunk.Lines))
+	for i, line := range hunk.Lines {
+		if !IsSuppressedLine(line) {
+			lines[i] = line
+		}
+	}
+	hunk.Lines = lines
+	return hunk
+}
+
+//LinesToSuppress returns the indices of the lines of the addition that the content detectors would flag on their own
+func LinesToSuppress(addition git_repo.Addition) []int {
+	fc := NewFileContentDetector()
+	patterns := NewPatternDetector().secretsPattern
+	var result []int
+	for i, line := range strings.Split(string(addition.Data), "\n") {
+		if IsSuppressedLine(line) {
+			continue
+		}
+		if len(fc.checkEachWord(line, checkBase64)) > 0 ||
+			len(fc.checkEachWord(line, checkHex)) > 0 ||
+			len(fc.checkEachWord(line, checkCreditCardNumber)) > 0 ||
+			patterns.check(line)[0] != "" {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+//SuppressLine returns the line with an InlineSuppressionMarker appended as a comment suitable for the given file
+func SuppressLine(fileName string, line string) string {
+	if IsSuppressedLine(line) {
+		return line
+	}
+	return strings.TrimRight(line, " \t\r") + " " + commentPrefixFor(fileName) + " " + InlineSuppressionMarker
+}
+
+func commentPrefixFor(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".go", ".java", ".js", ".ts", ".jsx", ".tsx", ".c", ".h", ".cpp", ".cc", ".cs", ".kt", ".scala", ".swift", ".rs", ".php", ".groovy", ".gradle":
+		return "//"
+	case ".sql", ".lua", ".hs":
+		return "--"
+	default:
+		return "#"
+	}
+}
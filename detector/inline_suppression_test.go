@@ -0,0 +1,44 @@
+package detector
+
+import (
+	"testing"
+
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldNotFlagSuppressedLines(t *testing.T) {
+	results := NewDetectionResults()
+	content := []byte("accessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY # talisman:ignore\n\"password\" : UnsafePassword // talisman:ignore")
+	additions := []git_repo.Addition{git_repo.NewAddition("filename", content)}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+	assert.False(t, results.HasFailures(), "Expected suppressed lines to not be flagged")
+}
+
+func TestShouldFlagLinesWithTheMarkerOutsideATrailingComment(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{
+		git_repo.NewAddition("key.yml", []byte("accessKey: \"wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY # talisman:ignore\"")),
+		git_repo.NewAddition("password.yml", []byte("\"password\" : UnsafePassword talisman:ignore")),
+	}
+
+	NewFileContentDetector().Test(additions, TalismanRCIgnore{}, results)
+	NewPatternDetector().Test(additions, TalismanRCIgnore{}, results)
+	assert.NotEmpty(t, results.GetFailures("key.yml"), "Expected the marker inside a string to not suppress the line")
+	assert.NotEmpty(t, results.GetFailures("password.yml"), "Expected the marker outside a comment to not suppress the line")
+}
+
+func TestLinesToSuppressOnlyReturnsFlaggedLines(t *testing.T) {
+	content := []byte("safe line\naccessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY\nanother safe line\n\"password\" : UnsafePassword")
+	lines := LinesToSuppress(git_repo.NewAddition("filename", content))
+	assert.Equal(t, []int{1, 3}, lines)
+}
+
+func TestSuppressLineUsesCommentSyntaxOfTheFile(t *testing.T) {
+	assert.Equal(t, "key: value # talisman:ignore", SuppressLine("config.yml", "key: value"))
+	assert.Equal(t, "key := value // talisman:ignore", SuppressLine("main.go", "key := value  "))
+	assert.Equal(t, "key := value // talisman:ignore", SuppressLine("main.go", "key := value // talisman:ignore"))
+}
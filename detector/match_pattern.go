@@ -1,6 +1,10 @@
 package detector
 
-import "regexp"
+import (
+	"regexp"
+
+	"talisman/git_repo"
+)
 
 type PatternMatcher struct {
 	regexes []*regexp.Regexp
@@ -20,6 +24,20 @@ func (detector PatternMatcher) check(content string) []string {
 	return []string{""}
 }
 
+//checkChanged returns the matches found in the hunk that span at least one of its added lines, so that patterns spanning several lines match even if only some of them changed
+func (detector PatternMatcher) checkChanged(hunk git_repo.Hunk) []string {
+	var detected []string
+	text := hunk.Text()
+	for _, regex := range detector.regexes {
+		for _, match := range regex.FindAllStringSubmatchIndex(text, -1) {
+			if len(match) > 3 && match[2] >= 0 && hunk.AddsLineWithin(match[0], match[1]) {
+				detected = append(detected, text[match[2]:match[3]])
+			}
+		}
+	}
+	return detected
+}
+
 func initPattern(patternStrings []string) *PatternMatcher {
 	var patterns = make([]*regexp.Regexp, len(patternStrings))
 	for i, pattern := range patternStrings {
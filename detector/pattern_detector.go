@@ -1,14 +1,18 @@
 package detector
 
 import (
-	"fmt"
 	"talisman/git_repo"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+//rsaPrivateKeyPattern matches a whole PEM encoded RSA private key, which spans several lines
+const rsaPrivateKeyPattern = "(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)"
+
 type PatternDetector struct {
 	secretsPattern *PatternMatcher
+	//multiLinePatterns are also matched against the context of the changes, as a change to a single line may be part of a multi-line secret
+	multiLinePatterns *PatternMatcher
 }
 
 //Test tests the contents of the Additions to ensure that they don't look suspicious
@@ -16,27 +20,32 @@ func (detector PatternDetector) Test(additions []git_repo.Addition, ignoreConfig
 	cc := NewChecksumCompare(additions, ignoreConfig)
 	for _, addition := range additions {
 		if ignoreConfig.Deny(addition, "filecontent") || cc.IsScanNotRequired(addition) {
-			log.WithFields(log.Fields{
+			result.Logger().WithFields(log.Fields{
 				"filePath": addition.Path,
 			}).Info("Ignoring addition as it was specified to be ignored.")
 			result.Ignore(addition.Path, "filecontent")
 			continue
 		}
-		detections := detector.secretsPattern.check(string(addition.Data))
+		addition, scan := ignoreConfig.Binary.contentToScan(addition, "filecontent", result)
+		if !scan {
+			continue
+		}
+		detections := detector.secretsPattern.check(withoutSuppressedLines(string(addition.Data)))
+		for _, hunk := range addition.Hunks {
+			detections = append(detections, detector.multiLinePatterns.checkChanged(withoutSuppressedHunkLines(hunk))...)
+		}
 		for _, detection := range detections {
-			if detection != "" {
+			if detection != "" && !ignoreConfig.Allowlist.ignoreIfAllowed(detection, addition.Path, "filecontent", result) {
 				if string(addition.Name) == DefaultRCFileName {
-					log.WithFields(log.Fields{
+					result.Logger().WithFields(log.Fields{
 						"filePath": addition.Path,
-						"pattern":  detection,
 					}).Warn("Warning file as it matched pattern.")
-					result.Warn(addition.Path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), addition.Commits)
+					result.WarnWithSecret(addition.Path, "filecontent", SecretPatternRuleID, "Potential secret pattern : %s", detection, addition.Commits)
 				} else {
-					log.WithFields(log.Fields{
+					result.Logger().WithFields(log.Fields{
 						"filePath": addition.Path,
-						"pattern":  detection,
 					}).Info("Failing file as it matched pattern.")
-					result.Fail(addition.Path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), addition.Commits)
+					result.FailWithSecret(addition.Path, "filecontent", SecretPatternRuleID, "Potential secret pattern : %s", detection, addition.Commits)
 				}
 			}
 		}
@@ -59,7 +68,7 @@ func NewPatternDetector() *PatternDetector {
 		"(?i)(<ConsumerSecret>\\S*<\\/ConsumerSecret>)",
 		"(?i)(AWS[ |\\w]+key[ |\\w]+[:|=])",
 		"(?i)(AWS[ |\\w]+secret[ |\\w]+[:|=])",
-		"(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)"}
+		rsaPrivateKeyPattern}
 
-	return &PatternDetector{NewSecretsPatternDetector(patternStrings)}
+	return &PatternDetector{NewSecretsPatternDetector(patternStrings), NewSecretsPatternDetector([]string{rsaPrivateKeyPattern})}
 }
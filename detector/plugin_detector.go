@@ -0,0 +1,200 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	//DefaultPluginTimeout is the time a plugin is given to report the findings of all the additions it is sent
+	DefaultPluginTimeout = 30 * time.Second
+
+	//maxPluginLineLength is the length of the longest finding a plugin may report
+	maxPluginLineLength = 1024 * 1024
+)
+
+//PluginConfig configures an external executable that is run as a detector, under the plugins key of .talismanrc
+type PluginConfig struct {
+	//Name is the category of the findings of the plugin, which is also what ignore_detectors refers to
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+	//Timeout is a duration such as 10s, DefaultPluginTimeout is used when it is not set
+	Timeout string `yaml:"timeout,omitempty"`
+	//Required makes the run fail when the plugin cannot be run or crashes, instead of only warning about it
+	Required bool `yaml:"required,omitempty"`
+}
+
+//pluginAddition is the JSON line written to the stdin of a plugin for every addition
+type pluginAddition struct {
+	Path    string   `json:"path"`
+	Content string   `json:"content"`
+	Commits []string `json:"commits,omitempty"`
+}
+
+//pluginFinding is the JSON line a plugin writes to its stdout for every finding
+type pluginFinding struct {
+	Path     string `json:"path"`
+	RuleID   string `json:"rule_id"`
+	Message  string `json:"message"`
+	Secret   string `json:"secret,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+//PluginDetector runs an external executable as a detector.
+//The additions are written to its stdin as JSON lines, which is closed afterwards, and the findings are read back as JSON lines from its stdout.
+//A plugin that cannot be started, times out, exits with an error or writes malformed findings does not affect the other detectors.
+type PluginDetector struct {
+	config PluginConfig
+}
+
+//NewPluginDetector returns a PluginDetector running the configured plugin
+func NewPluginDetector(config PluginConfig) *PluginDetector {
+	return &PluginDetector{config: config}
+}
+
+//PluginDetectors returns a detector for every plugin configured in .talismanrc
+func PluginDetectors(ignoreConfig TalismanRCIgnore) []Detector {
+	var result []Detector
+	for _, plugin := range ignoreConfig.Plugins {
+		result = append(result, NewPluginDetector(plugin))
+	}
+	return result
+}
+
+//Test sends the additions that are not ignored for the plugin to it and records the findings it reports
+func (p *PluginDetector) Test(additions []git_repo.Addition, ignoreConfig TalismanRCIgnore, result *DetectionResults) {
+	var tested []git_repo.Addition
+	for _, addition := range additions {
+		if ignoreConfig.Deny(addition, p.config.Name) {
+			result.Logger().WithFields(log.Fields{
+				"filePath": addition.Path,
+				"plugin":   p.config.Name,
+			}).Info("Ignoring addition as it was specified to be ignored.")
+			result.Ignore(addition.Path, p.config.Name)
+			continue
+		}
+		tested = append(tested, addition)
+	}
+	if len(tested) == 0 {
+		return
+	}
+	findings, err := p.run(tested)
+	if err != nil {
+		message := fmt.Sprintf("Plugin %s failed: %v", p.config.Name, err)
+		result.Logger().WithFields(log.Fields{
+			"plugin": p.config.Name,
+		}).Error(message)
+		if p.config.Required {
+			result.Fail(git_repo.FilePath(DefaultRCFileName), ErrorCategory, message, []string{})
+		} else {
+			result.Warn(git_repo.FilePath(DefaultRCFileName), ErrorCategory, message, []string{})
+		}
+	}
+	for _, finding := range findings {
+		p.record(finding, tested, ignoreConfig.Allowlist, result)
+	}
+}
+
+func (p *PluginDetector) run(additions []git_repo.Addition) ([]pluginFinding, error) {
+	if p.config.Name == "" || p.config.Command == "" {
+		return nil, fmt.Errorf("both name and command need to be configured")
+	}
+	timeout := DefaultPluginTimeout
+	if p.config.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(p.config.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, addition := range additions {
+		encoder.Encode(pluginAddition{Path: string(addition.Path), Content: string(addition.Data), Commits: addition.Commits})
+	}
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, p.config.Command, p.config.Args...)
+	command.Stdin = &input
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err := command.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%v: %s", err, message)
+		}
+		return nil, err
+	}
+
+	var findings []pluginFinding
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(nil, maxPluginLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var finding pluginFinding
+		if err := json.Unmarshal([]byte(line), &finding); err != nil {
+			return findings, fmt.Errorf("malformed finding %q: %v", line, err)
+		}
+		findings = append(findings, finding)
+	}
+	return findings, scanner.Err()
+}
+
+func (p *PluginDetector) record(finding pluginFinding, additions []git_repo.Addition, allowlist Allowlist, result *DetectionResults) {
+	var addition *git_repo.Addition
+	for i := range additions {
+		if string(additions[i].Path) == finding.Path {
+			addition = &additions[i]
+		}
+	}
+	if addition == nil {
+		result.Logger().WithFields(log.Fields{
+			"filePath": finding.Path,
+			"plugin":   p.config.Name,
+		}).Warn("Dropping finding of plugin for a path it was not sent.")
+		return
+	}
+	ruleID := finding.RuleID
+	if ruleID == "" {
+		ruleID = p.config.Name
+	}
+	warning := finding.Severity == "warning"
+	if finding.Secret == "" {
+		if warning {
+			result.Warn(addition.Path, p.config.Name, finding.Message, addition.Commits)
+		} else {
+			result.Fail(addition.Path, p.config.Name, finding.Message, addition.Commits)
+		}
+		return
+	}
+	if allowlist.ignoreIfAllowed(finding.Secret, addition.Path, p.config.Name, result) {
+		return
+	}
+	message := finding.Message
+	if !strings.Contains(message, "%s") {
+		message += ": %s"
+	}
+	if warning {
+		result.WarnWithSecret(addition.Path, p.config.Name, ruleID, message, finding.Secret, addition.Commits)
+	} else {
+		result.FailWithSecret(addition.Path, p.config.Name, ruleID, message, finding.Secret, addition.Commits)
+	}
+}
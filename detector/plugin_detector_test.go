@@ -0,0 +1,105 @@
+package detector
+
+import (
+	"testing"
+
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const passwordPluginScript = `while read -r line; do
+	case "$line" in
+	*hunter2*)
+		path=$(echo "$line" | sed 's/^{"path":"\([^"]*\)".*/\1/')
+		echo "{\"path\":\"$path\",\"rule_id\":\"corp-password\",\"message\":\"Expected file to not to contain passwords such as: %s\",\"secret\":\"hunter2\"}";;
+	esac
+done`
+
+func shellPlugin(script string) PluginConfig {
+	return PluginConfig{Name: "corp", Command: "sh", Args: []string{"-c", script}}
+}
+
+func TestShouldRecordFindingsOfPlugins(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{
+		git_repo.NewAddition("readme.txt", []byte("nothing to see here")),
+		git_repo.NewAddition("config/app.properties", []byte("password=hunter2")),
+	}
+	NewPluginDetector(shellPlugin(passwordPluginScript)).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures(), "Expected the finding of the plugin to fail the run")
+	assert.Len(t, results.Results, 1)
+	failure := results.Results[0].FailureList[0]
+	assert.Equal(t, git_repo.FilePath("config/app.properties"), results.Results[0].Filename)
+	assert.Equal(t, "corp", failure.Category)
+	assert.Equal(t, "corp-password", failure.RuleID)
+	assert.NotContains(t, failure.Message, "hunter2", "Expected the secret to be redacted")
+}
+
+func TestShouldNotSendIgnoredAdditionsToPlugins(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("config/app.properties", []byte("password=hunter2"))}
+	ignores := TalismanRCIgnore{FileIgnoreConfig: []FileIgnoreConfig{{FileName: "config/app.properties", IgnoreDetectors: []string{"corp"}}}}
+	NewPluginDetector(shellPlugin(passwordPluginScript)).Test(additions, ignores, results)
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
+
+func TestShouldWarnAboutCrashingPlugins(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("readme.txt", []byte("nothing to see here"))}
+	NewPluginDetector(shellPlugin("echo broken >&2; exit 3")).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.False(t, results.HasFailures(), "Expected a crash of an optional plugin to not fail the run")
+	assert.True(t, results.HasWarnings())
+	assert.Contains(t, results.Results[0].WarningList[0].Message, "broken")
+}
+
+func TestShouldFailWhenRequiredPluginCannotBeRun(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("readme.txt", []byte("nothing to see here"))}
+	NewPluginDetector(PluginConfig{Name: "corp", Command: "/nonexistent/plugin", Required: true}).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures())
+	assert.Equal(t, git_repo.FilePath(DefaultRCFileName), results.Results[0].Filename)
+}
+
+func TestShouldStopPluginsThatTimeOut(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("readme.txt", []byte("nothing to see here"))}
+	plugin := PluginConfig{Name: "corp", Command: "sleep", Args: []string{"10"}, Timeout: "100ms", Required: true}
+	NewPluginDetector(plugin).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.True(t, results.HasFailures())
+	assert.Contains(t, results.Results[0].FailureList[0].Message, "timed out")
+}
+
+func TestShouldKeepFindingsReportedBeforeMalformedOutput(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("app.properties", []byte("password=hunter2"))}
+	script := `cat > /dev/null; echo '{"path":"app.properties","message":"suspicious"}'; echo 'not json'; echo '{"path":"other.txt","message":"unknown"}'`
+	NewPluginDetector(shellPlugin(script)).Test(additions, TalismanRCIgnore{}, results)
+
+	assert.Len(t, results.Results, 2, "Expected the finding and the warning about the malformed output only")
+	assert.Equal(t, "suspicious", results.Results[1].FailureList[0].Message)
+	assert.True(t, results.HasWarnings())
+}
+
+func TestShouldReadPluginsFromTalismanRC(t *testing.T) {
+	talismanRC := NewTalismanRCIgnore([]byte("plugins:\n- name: corp\n  command: ./corp-detector\n  args: [--strict]\n  timeout: 5s\n"))
+
+	assert.Equal(t, []PluginConfig{{Name: "corp", Command: "./corp-detector", Args: []string{"--strict"}, Timeout: "5s"}}, talismanRC.Plugins)
+	assert.Len(t, PluginDetectors(talismanRC), 1)
+}
+
+func TestShouldIgnoreAllowlistedFindingsOfPlugins(t *testing.T) {
+	results := NewDetectionResults()
+	additions := []git_repo.Addition{git_repo.NewAddition("config/app.properties", []byte("password=hunter2"))}
+	ignores := TalismanRCIgnore{Allowlist: Allowlist{Values: []string{"hunter2"}}}
+	NewPluginDetector(shellPlugin(passwordPluginScript)).Test(additions, ignores, results)
+
+	assert.False(t, results.HasFailures(), "Expected the allowlisted finding of the plugin not to fail")
+	assert.Equal(t, "corp", results.Results[0].IgnoreList[0].Category)
+}
@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	//PartialRedaction keeps a few characters at both ends of a secret so that it can still be located
+	PartialRedaction string = "partial"
+	//FullRedaction keeps none of the characters of a secret, only its length and hash
+	FullRedaction string = "full"
+
+	defaultVisibleCharacters = 4
+	secretHashLength         = 16
+)
+
+//RedactionPolicy decides how much of a matched secret ends up in messages and reports.
+//It is configured under the redaction key of .talismanrc, while ShowSecrets can only be turned on from the command line.
+type RedactionPolicy struct {
+	Mode              string `yaml:"mode,omitempty"`
+	VisibleCharacters int    `yaml:"visible_characters,omitempty"`
+	ShowSecrets       bool   `yaml:"-"`
+}
+
+//SecretPreview is a redacted representation of a matched secret that is safe to print and store
+type SecretPreview struct {
+	Preview string `json:"preview"`
+	Length  int    `json:"length"`
+	Hash    string `json:"hash"`
+}
+
+//Validate returns an error for a redaction mode other than partial or full, so that a typo does not silently reveal more of the secrets than intended
+func (p RedactionPolicy) Validate() error {
+	if p.Mode != "" && p.Mode != PartialRedaction && p.Mode != FullRedaction {
+		return fmt.Errorf("unknown redaction mode %q, expected one of %v", p.Mode, []string{PartialRedaction, FullRedaction})
+	}
+	return nil
+}
+
+//Redact returns the preview of the secret allowed by the policy
+func (p RedactionPolicy) Redact(secret string) *SecretPreview {
+	return &SecretPreview{
+		Preview: p.preview(secret),
+		Length:  len(secret),
+		Hash:    hashSecret(secret),
+	}
+}
+
+func (p RedactionPolicy) preview(secret string) string {
+	if p.ShowSecrets {
+		return secret
+	}
+	if p.Mode == FullRedaction {
+		return "[REDACTED]"
+	}
+	visible := p.VisibleCharacters
+	if visible <= 0 {
+		visible = defaultVisibleCharacters
+	}
+	//never reveal more than half of the secret
+	if visible*4 > len(secret) {
+		visible = len(secret) / 4
+	}
+	return secret[:visible] + "..." + secret[len(secret)-visible:]
+}
+
+func (s SecretPreview) String() string {
+	return fmt.Sprintf("%s (%d chars, sha256:%s)", s.Preview, s.Length, s.Hash)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:secretHashLength]
+}
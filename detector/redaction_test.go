@@ -0,0 +1,42 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialRedactionKeepsOnlyTheEndsOfTheSecret(t *testing.T) {
+	preview := RedactionPolicy{}.Redact("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	assert.Equal(t, "wJal...EKEY", preview.Preview)
+	assert.Equal(t, 40, preview.Length)
+	assert.Len(t, preview.Hash, 16)
+}
+
+func TestPartialRedactionNeverRevealsMoreThanHalfOfTheSecret(t *testing.T) {
+	preview := RedactionPolicy{VisibleCharacters: 10}.Redact("hunter2hunter")
+	assert.Equal(t, "hun...ter", preview.Preview)
+}
+
+func TestFullRedactionRevealsNoCharacters(t *testing.T) {
+	preview := RedactionPolicy{Mode: FullRedaction}.Redact("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	assert.Equal(t, "[REDACTED]", preview.Preview)
+	assert.False(t, strings.Contains(preview.String(), "wJal"))
+}
+
+func TestShowingSecretsKeepsTheWholeSecret(t *testing.T) {
+	preview := RedactionPolicy{Mode: FullRedaction, ShowSecrets: true}.Redact("hunter2")
+	assert.Equal(t, "hunter2", preview.Preview)
+}
+
+func TestRedactionPolicyIsReadFromTalismanRC(t *testing.T) {
+	rc := NewTalismanRCIgnore([]byte("redaction:\n  mode: full\n  visible_characters: 2\n"))
+	assert.Equal(t, RedactionPolicy{Mode: FullRedaction, VisibleCharacters: 2}, rc.Redaction)
+}
+
+func TestParsingTalismanRCShouldRejectUnknownRedactionModes(t *testing.T) {
+	_, err := ParseTalismanRCIgnore([]byte("redaction:\n  mode: ful\n"))
+
+	assert.Error(t, err)
+}
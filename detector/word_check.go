@@ -9,6 +9,8 @@ import (
 )
 
 type WordCheck struct {
+	//logger receives the debug logs of the check, the standard logger when nil
+	logger *log.Logger
 }
 
 const AVERAGE_LENGTH_OF_WORDS_IN_ENGLISH = 5 //See http://bit.ly/2qYFzFf for reference
@@ -21,14 +23,21 @@ func (en *WordCheck) containsWordsOnly(text string) bool {
 	if reader == nil {
 		return false
 	}
-	wordCount := howManyWordsExistInText(reader, text)
+	wordCount := howManyWordsExistInText(reader, text, en.log())
 	if wordCount >= (len(text) / (AVERAGE_LENGTH_OF_WORDS_IN_ENGLISH)) {
 		return true
 	}
 	return false
 }
 
-func howManyWordsExistInText(reader *bufio.Reader, text string) int {
+func (en *WordCheck) log() *log.Logger {
+	if en == nil || en.logger == nil {
+		return log.StandardLogger()
+	}
+	return en.logger
+}
+
+func howManyWordsExistInText(reader *bufio.Reader, text string, logger *log.Logger) int {
 	wordCount := 0
 	for {
 		word, err := reader.ReadString('\n')
@@ -40,7 +49,7 @@ func howManyWordsExistInText(reader *bufio.Reader, text string) int {
 		}
 
 		if err != nil { //EOF
-			log.Debugf("[WordChecker]: Found %d words", wordCount)
+			logger.Debugf("[WordChecker]: Found %d words", wordCount)
 			break
 		}
 	}
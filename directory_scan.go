@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"talisman/detector"
+	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bmatcuk/doublestar"
+)
+
+//excludeFileNames are the files whose patterns exclude paths from a directory scan, in the directory they are found in and below
+var excludeFileNames = []string{".gitignore", detector.DefaultIgnoreFileName}
+
+//ReadError represents a file that could not be read
+type ReadError struct {
+	Path string
+	Err  error
+	//Warning states that the file has no content that could be verified, e.g. a broken symlink, so it is reported without failing the run
+	Warning bool
+}
+
+func (e ReadError) String() string {
+	return fmt.Sprintf("Unable to read %s: %v", e.Path, e.Err)
+}
+
+//DirectoryScan walks a directory tree, independently of git, and provides every file in it as an addition.
+//Paths excluded by .gitignore or .talismanignore files and directories already visited through symlinks are skipped.
+//Binary files are provided as well, the detectors apply the binary policy of the .talismanrc to them.
+type DirectoryScan struct {
+	root       string
+	visited    map[string]bool
+	additions  []git_repo.Addition
+	readErrors []ReadError
+}
+
+//NewDirectoryScan returns a DirectoryScan of the directory tree rooted at root
+func NewDirectoryScan(root string) *DirectoryScan {
+	return &DirectoryScan{root: root, visited: map[string]bool{}}
+}
+
+//GetAdditions walks the directory tree and returns its files as additions, along with the files that could not be read
+func (d *DirectoryScan) GetAdditions() ([]git_repo.Addition, []ReadError) {
+	d.walk(d.root, "", nil)
+	return d.additions, d.readErrors
+}
+
+func (d *DirectoryScan) walk(directory string, relativeDirectory string, excludes []excludePattern) {
+	realPath, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		d.readErrors = append(d.readErrors, ReadError{Path: directory, Err: err})
+		return
+	}
+	if d.visited[realPath] {
+		log.Debugf("Skipping %s as it was already scanned, it is part of a symlink loop or linked to twice", directory)
+		return
+	}
+	d.visited[realPath] = true
+	excludes = append([]excludePattern{}, excludes...)
+	for _, excludeFileName := range excludeFileNames {
+		excludes = append(excludes, readExcludePatterns(filepath.Join(directory, excludeFileName), relativeDirectory)...)
+	}
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil {
+		d.readErrors = append(d.readErrors, ReadError{Path: directory, Err: err})
+		return
+	}
+	for _, entry := range entries {
+		filePath := filepath.Join(directory, entry.Name())
+		relativePath := path.Join(relativeDirectory, entry.Name())
+		info, err := os.Stat(filePath)
+		if err != nil {
+			if !isExcluded(excludes, relativePath, false) {
+				//a broken symlink has no content to verify, but its target may be missing by mistake
+				d.readErrors = append(d.readErrors, ReadError{Path: filePath, Err: err, Warning: true})
+			}
+			continue
+		}
+		if entry.Name() == ".git" || isExcluded(excludes, relativePath, info.IsDir()) {
+			log.Debugf("Skipping excluded path %s", filePath)
+			continue
+		}
+		if info.IsDir() {
+			d.walk(filePath, relativePath, excludes)
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		d.addFile(filePath)
+	}
+}
+
+func (d *DirectoryScan) addFile(filePath string) {
+	data, err := ReadFile(filePath)
+	if err != nil {
+		d.readErrors = append(d.readErrors, ReadError{Path: filePath, Err: err})
+		return
+	}
+	d.additions = append(d.additions, git_repo.NewAddition(filePath, data))
+}
+
+//excludePattern is a single pattern of a .gitignore style exclude file
+type excludePattern struct {
+	pattern       string
+	base          string
+	negated       bool
+	directoryOnly bool
+	anchored      bool
+}
+
+//readExcludePatterns reads the patterns of an exclude file following the rules of gitignore:
+//only lines starting with # are comments, leading whitespace is part of the pattern,
+//trailing spaces are ignored unless escaped with a backslash, and \# and \! start patterns with a literal # or !
+func readExcludePatterns(excludeFile string, base string) []excludePattern {
+	contents, err := ioutil.ReadFile(excludeFile)
+	if err != nil {
+		return nil
+	}
+	var patterns []excludePattern
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = trimUnescapedTrailingSpaces(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern := excludePattern{base: base}
+		if strings.HasPrefix(line, "!") {
+			pattern.negated = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.directoryOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		pattern.anchored = strings.Contains(line, "/")
+		pattern.pattern = globOf(strings.TrimPrefix(line, "/"))
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+func trimUnescapedTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+//globOf returns the doublestar glob matching what the gitignore pattern matches.
+//Backslash escapes mean the same to both, but braces are literal characters in gitignore patterns.
+func globOf(pattern string) string {
+	var glob strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			glob.WriteByte(pattern[i])
+			if i+1 < len(pattern) {
+				i++
+				glob.WriteByte(pattern[i])
+			}
+		case '{', '}':
+			glob.WriteByte('\\')
+			glob.WriteByte(pattern[i])
+		default:
+			glob.WriteByte(pattern[i])
+		}
+	}
+	return glob.String()
+}
+
+func (p excludePattern) matches(relativePath string, isDirectory bool) bool {
+	if p.directoryOnly && !isDirectory {
+		return false
+	}
+	if p.base != "" {
+		if !strings.HasPrefix(relativePath, p.base+"/") {
+			return false
+		}
+		relativePath = strings.TrimPrefix(relativePath, p.base+"/")
+	}
+	if !p.anchored {
+		relativePath = path.Base(relativePath)
+	}
+	matched, _ := doublestar.Match(p.pattern, relativePath)
+	return matched
+}
+
+//isExcluded applies the patterns in order, so that later patterns, including negated ones, take precedence over earlier ones
+func isExcluded(patterns []excludePattern, relativePath string, isDirectory bool) bool {
+	excluded := false
+	for _, pattern := range patterns {
+		if pattern.matches(relativePath, isDirectory) {
+			excluded = !pattern.negated
+		}
+	}
+	return excluded
+}
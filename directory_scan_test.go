@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludePatternsFollowTheGitignoreRules(t *testing.T) {
+	withNewTmpDirNamed("talisman-exclude-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, ".gitignore", "# a comment\nfoo #bar\n\\#file\n\\!important\ntrailing\\ \n  indented\nspaces   \n")
+		patterns := readExcludePatterns(filepath.Join(dir, ".gitignore"), "")
+
+		assert.True(t, isExcluded(patterns, "foo #bar", false), "Expected a # inside a pattern to be part of it")
+		assert.False(t, isExcluded(patterns, "foo", false), "Expected a # inside a pattern to not start a comment")
+		assert.True(t, isExcluded(patterns, "#file", false), "Expected an escaped # to start a pattern")
+		assert.False(t, isExcluded(patterns, "# a comment", false), "Expected a leading # to start a comment")
+		assert.True(t, isExcluded(patterns, "!important", false), "Expected an escaped ! to be matched literally")
+		assert.True(t, isExcluded(patterns, "trailing ", false), "Expected an escaped trailing space to be kept")
+		assert.True(t, isExcluded(patterns, "  indented", false), "Expected leading whitespace to be part of the pattern")
+		assert.False(t, isExcluded(patterns, "indented", false), "Expected leading whitespace to be part of the pattern")
+		assert.True(t, isExcluded(patterns, "spaces", false), "Expected unescaped trailing spaces to be ignored")
+	})
+}
+
+func TestDirectoryScanWarnsAboutBrokenSymlinks(t *testing.T) {
+	withNewTmpDirNamed("talisman-scan-dir-test", func(dir string) {
+		defer os.RemoveAll(dir)
+		writeFile(dir, "readme.txt", "nothing to see here")
+		os.Symlink("missing.txt", filepath.Join(dir, "link.txt"))
+
+		_, readErrors := NewDirectoryScan(dir).GetAdditions()
+
+		assert.Len(t, readErrors, 1)
+		assert.Equal(t, filepath.Join(dir, "link.txt"), readErrors[0].Path)
+		assert.True(t, readErrors[0].Warning, "Expected a broken symlink to be reported as a warning")
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"talisman/git_repo"
+)
+
+//FileListHook provides the files of a NUL-separated list, such as the output of `git diff --name-only -z`, as additions
+type FileListHook struct {
+	list io.Reader
+}
+
+func NewFileListHook(list io.Reader) *FileListHook {
+	return &FileListHook{list: list}
+}
+
+//GetAdditions returns the listed files as additions, along with the listed files that could not be read
+func (p *FileListHook) GetAdditions() ([]git_repo.Addition, []ReadError) {
+	contents, err := ioutil.ReadAll(p.list)
+	if err != nil {
+		return nil, []ReadError{{Path: "file list", Err: err}}
+	}
+	var additions []git_repo.Addition
+	var readErrors []ReadError
+	for _, file := range strings.Split(string(contents), "\x00") {
+		file = strings.TrimSuffix(file, "\n")
+		if file == "" {
+			continue
+		}
+		data, err := ReadFile(file)
+		if err != nil {
+			readErrors = append(readErrors, ReadError{Path: file, Err: err})
+			continue
+		}
+		additions = append(additions, git_repo.NewAddition(file, data))
+	}
+	return additions, readErrors
+}
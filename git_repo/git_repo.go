@@ -1,12 +1,14 @@
 package git_repo
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
@@ -24,27 +26,83 @@ type Addition struct {
 	Name    FileName
 	Commits []string
 	Data    []byte
+	//Blob is the hash of the git object the data was read from, when known
+	Blob string
+	//OldPath is the path the file was renamed or copied from, when git detected a rename or copy
+	OldPath FilePath
+	//Similarity is the percentage of content the file shares with the file at OldPath
+	Similarity int
+	//Copied states whether the file at OldPath still exists, i.e. the file was copied rather than renamed
+	Copied bool
+	//Hunks are the changed sections of the file along with their context lines, when only the changes to the file were read.
+	//The Data then holds the added lines only.
+	Hunks []Hunk
+	//Size is the size in bytes of the whole file, when only the changes to the file were read
+	Size int
+	//changesReadFrom is the root of the repo the whole blob can be read from, when only the changes to the file were read
+	changesReadFrom string
+}
+
+//fileChange represents a file that was added, modified, renamed or copied in a diff
+type fileChange struct {
+	path       string
+	oldPath    string
+	similarity int
+	copied     bool
+	blob       string
 }
 
 //GitRepo represents a Git repository located at the absolute path represented by root
 type GitRepo struct {
-	root string
+	root         string
+	contextLines int
+	fullFiles    bool
 }
 
 //RepoLocatedAt returns a new GitRepo with it's root located at the location specified by the argument.
 //If the argument is not an absolute path, it will be turned into one.
 func RepoLocatedAt(path string) GitRepo {
 	absoluteRoot, _ := filepath.Abs(path)
-	return GitRepo{absoluteRoot}
+	return GitRepo{root: absoluteRoot, contextLines: DefaultContextLines}
+}
+
+//WithContextLines returns the repo reading the given number of unchanged lines around every changed section of a file
+func (repo GitRepo) WithContextLines(contextLines int) GitRepo {
+	repo.contextLines = contextLines
+	return repo
+}
+
+//WithFullFiles returns the repo reading the whole new version of every changed file instead of its changed sections only
+func (repo GitRepo) WithFullFiles(fullFiles bool) GitRepo {
+	repo.fullFiles = fullFiles
+	return repo
 }
 
-//Gets all the staged files and collects the diff section in each file
+//RepoContaining returns a new GitRepo rooted at the top level of the working tree containing the path, which may be a linked worktree or a submodule.
+//If the path is not inside a working tree, the repo is located at the path itself.
+func RepoContaining(path string) GitRepo {
+	repo := RepoLocatedAt(path)
+	command := exec.Command("git", "rev-parse", "--show-toplevel")
+	command.Dir = repo.root
+	topLevel, err := command.Output()
+	if err != nil || len(strings.TrimSpace(string(topLevel))) == 0 {
+		return repo
+	}
+	repo.root = RepoLocatedAt(strings.TrimSpace(string(topLevel))).root
+	return repo
+}
+
+//Root returns the absolute path of the working tree of the repo
+func (repo GitRepo) Root() string {
+	return repo.root
+}
+
+//Gets all the staged files and collects the diff section in each file, as read from the index
 func (repo GitRepo) GetDiffForStagedFiles() []Addition {
 	files := repo.stagedFiles()
 	result := make([]Addition, len(files))
 	for i, file := range files {
-		data := repo.fetchStagedDiff(file)
-		result[i] = NewAddition(file, data)
+		result[i] = repo.mustRead(repo.changedAddition(file, "--staged"))
 	}
 
 	log.WithFields(log.Fields{
@@ -57,8 +115,8 @@ func (repo GitRepo) StagedAdditions() []Addition {
 	files := repo.stagedFiles()
 	result := make([]Addition, len(files))
 	for i, file := range files {
-		data := repo.stagedVersionOfFile(file)
-		result[i] = NewAddition(file, data)
+		data := repo.stagedVersionOfFile(file.path)
+		result[i] = file.addition(data)
 	}
 
 	log.WithFields(log.Fields{
@@ -72,20 +130,36 @@ func (repo GitRepo) AllAdditions() []Addition {
 	return repo.AdditionsWithinRange("origin/master", "master")
 }
 
-//Additions returns the outgoing additions and modifications in a GitRepo that are in the given commit range. This does not include files that were deleted.
+//Additions returns the outgoing additions and modifications in a GitRepo that are in the given commit range, as read from the new commit.
+//This does not include files that were deleted.
 func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) []Addition {
-	files := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
+	result, err := repo.AdditionsWithinRangeOrError(oldCommit, newCommit)
+	if err != nil {
+		log.WithError(err).Fatal("Git command execution failed")
+	}
+	return result
+}
+
+//AdditionsWithinRangeOrError returns the same additions as AdditionsWithinRange, but returns an error rather than exiting when a git command fails,
+//e.g. for a server that has to outlive a bad request
+func (repo GitRepo) AdditionsWithinRangeOrError(oldCommit string, newCommit string) ([]Addition, error) {
+	rawDiff, err := repo.runRepoCommand("git", "diff", oldCommit+".."+newCommit, "--raw", "--no-abbrev", "-M", "-C", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	files := nonSubmoduleChanges(string(rawDiff))
 	result := make([]Addition, len(files))
 	for i, file := range files {
-		data, _ := repo.ReadRepoFile(file)
-		result[i] = NewAddition(file, data)
+		if result[i], err = repo.changedAddition(file, oldCommit+".."+newCommit); err != nil {
+			return nil, err
+		}
 	}
 	log.WithFields(log.Fields{
 		"oldCommit": oldCommit,
 		"newCommit": newCommit,
 		"additions": result,
 	}).Info("Generating all additions in range.")
-	return result
+	return result, nil
 }
 
 //NewAddition returns a new Addition for a file with supplied name and contents
@@ -97,6 +171,91 @@ func NewAddition(filePath string, content []byte) Addition {
 	}
 }
 
+func (change fileChange) addition(content []byte) Addition {
+	addition := NewAddition(change.path, content)
+	addition.OldPath = FilePath(change.oldPath)
+	addition.Similarity = change.similarity
+	addition.Copied = change.copied
+	return addition
+}
+
+//changedAddition reads the changed file from the git objects of the diff. Unless the repo reads full files, only the changed sections of text files are read.
+//Pure renames have no changed sections, they are read in full so that their content can be checked against the checksum approved under their old path.
+func (repo GitRepo) changedAddition(file fileChange, diffArgs ...string) (Addition, error) {
+	if repo.fullFiles {
+		content, err := repo.blobContent(file.blob)
+		addition := file.addition(content)
+		addition.Blob = file.blob
+		return addition, err
+	}
+	args := append([]string{"diff", "--no-color", "--no-ext-diff", "-M", "-C", "-U" + strconv.Itoa(repo.contextLines)}, diffArgs...)
+	args = append(args, "--", file.path)
+	if file.oldPath != "" {
+		args = append(args, file.oldPath)
+	}
+	diff, err := repo.runRepoCommand("git", args...)
+	if err != nil {
+		return Addition{}, err
+	}
+	hunks, added, binary := parseUnifiedDiff(string(diff))
+	if binary || (file.oldPath != "" && !file.copied && file.similarity == 100) {
+		if added, err = repo.blobContent(file.blob); err != nil {
+			return Addition{}, err
+		}
+		hunks = nil
+	}
+	addition := file.addition(added)
+	addition.Blob = file.blob
+	addition.Hunks = hunks
+	if addition.Size, err = repo.blobSize(file.blob); err != nil {
+		return Addition{}, err
+	}
+	if hunks != nil || len(added) == 0 {
+		addition.changesReadFrom = repo.root
+	}
+	return addition, nil
+}
+
+//mustRead exits when an addition could not be read, as the hooks cannot verify what they cannot read
+func (repo GitRepo) mustRead(addition Addition, err error) Addition {
+	if err != nil {
+		log.WithError(err).Fatal("Git command execution failed")
+	}
+	return addition
+}
+
+//WholeContent returns the whole content of the file the addition was read from, which is read from the git objects when only the changes to the file were read.
+//It is nil if the blob cannot be read.
+func (a Addition) WholeContent() []byte {
+	if a.changesReadFrom == "" {
+		return a.Data
+	}
+	command := exec.Command("git", "cat-file", "blob", a.Blob)
+	command.Dir = a.changesReadFrom
+	content, err := command.Output()
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+func (repo GitRepo) blobContent(blob string) ([]byte, error) {
+	return repo.runRepoCommand("git", "cat-file", "blob", blob)
+}
+
+func (repo GitRepo) blobSize(blob string) (int, error) {
+	size, err := repo.runRepoCommand("git", "cat-file", "-s", blob)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(size)))
+}
+
+//IsPureRename states whether the file was renamed without any change to its content
+func (a Addition) IsPureRename() bool {
+	return a.OldPath != "" && !a.Copied && a.Similarity == 100
+}
+
 func NewScannerAddition(filePath string, commits []string, content []byte) Addition {
 	return Addition{
 		Path:    FilePath(filePath),
@@ -123,6 +282,88 @@ func (repo GitRepo) ReadRepoFileOrNothing(fileName string) ([]byte, error) {
 	return make([]byte, 0), nil
 }
 
+//ReadCommitFileOrNothing returns the contents of the supplied relative filename as committed in the given commit.
+//If the commit does not contain the file, then an empty array of bytes is returned for the content.
+func (repo GitRepo) ReadCommitFileOrNothing(commit string, fileName string) []byte {
+	command := exec.Command("git", "show", commit+":"+fileName)
+	command.Dir = repo.root
+	content, err := command.Output()
+	if err != nil {
+		return make([]byte, 0)
+	}
+	return content
+}
+
+//GitDir returns the absolute path of the directory git keeps the repository data in, usually .git in the root of the repository
+func (repo GitRepo) GitDir() string {
+	gitDir := strings.TrimSpace(string(repo.executeRepoCommand("git", "rev-parse", "--git-dir")))
+	if filepath.IsAbs(gitDir) {
+		return gitDir
+	}
+	return filepath.Join(repo.root, gitDir)
+}
+
+//CommonDir returns the absolute path of the directory git keeps the data shared by all the worktrees of the repository in, e.g. objects and refs.
+//It is the same as GitDir except in linked worktrees.
+func (repo GitRepo) CommonDir() string {
+	commonDir := strings.TrimSpace(string(repo.executeRepoCommand("git", "rev-parse", "--git-common-dir")))
+	if filepath.IsAbs(commonDir) {
+		return commonDir
+	}
+	return filepath.Join(repo.root, commonDir)
+}
+
+//WriteRepoFile replaces the contents of the supplied relative filename in the working tree of the git repo
+func (repo GitRepo) WriteRepoFile(fileName string, data []byte) error {
+	path := filepath.Join(repo.root, fileName)
+	log.Debugf("writing file %s", path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return ioutil.WriteFile(path, data, 0644)
+	}
+	return ioutil.WriteFile(path, data, info.Mode())
+}
+
+//ReadStagedFile returns the contents of the supplied relative filename as staged in the index
+func (repo GitRepo) ReadStagedFile(fileName string) ([]byte, error) {
+	command := exec.Command("git", "show", ":"+fileName)
+	command.Dir = repo.root
+	return command.Output()
+}
+
+//StageContent replaces the staged version of the supplied relative filename with the given data, leaving the working tree and the file mode untouched
+func (repo GitRepo) StageContent(fileName string, data []byte) error {
+	command := exec.Command("git", "ls-files", "-s", "--", fileName)
+	command.Dir = repo.root
+	entry, err := command.Output()
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(entry))
+	if len(fields) == 0 {
+		return fmt.Errorf("%s is not staged", fileName)
+	}
+	command = exec.Command("git", "hash-object", "-w", "--no-filters", "--stdin")
+	command.Dir = repo.root
+	command.Stdin = bytes.NewReader(data)
+	blob, err := command.Output()
+	if err != nil {
+		return err
+	}
+	command = exec.Command("git", "update-index", "--cacheinfo", fields[0]+","+strings.TrimSpace(string(blob))+","+fileName)
+	command.Dir = repo.root
+	return command.Run()
+}
+
+//Unstage removes the supplied relative filename from the index, leaving the working tree untouched
+func (repo GitRepo) Unstage(fileName string) {
+	if !repo.hasBranch() {
+		repo.executeRepoCommand("git", "rm", "--cached", "-q", "--", fileName)
+		return
+	}
+	repo.executeRepoCommand("git", "reset", "-q", "HEAD", "--", fileName)
+}
+
 //CheckIfFileExists checks if the file exists on the file system. Does not look into the file contents
 //Returns TRUE if file exists
 //Returns FALSE if the file is not found
@@ -175,14 +416,30 @@ func (repo GitRepo) trackedFilePaths() []string {
 	return trackedFilePaths
 }
 
-func (repo GitRepo) stagedFiles() []string {
-	stagedFiles := strings.Split(repo.fetchStagedChanges(), "\n")
-	var result []string
-	for _, c := range stagedFiles {
-		if len(c) != 0 {
-			file := strings.Split(c, "\t")[1]
-			result = append(result, file)
+func (repo GitRepo) stagedFiles() []fileChange {
+	return nonSubmoduleChanges(repo.fetchStagedChanges())
+}
+
+//nonSubmoduleChanges returns the changed files of a raw diff, leaving out submodules as their content lives in another repository.
+//Renamed and copied files carry the path they were renamed or copied from along with their similarity.
+func nonSubmoduleChanges(rawDiff string) []fileChange {
+	var result []fileChange
+	for _, c := range strings.Split(rawDiff, "\n") {
+		if len(c) == 0 {
+			continue
+		}
+		change := strings.Split(c, "\t")
+		fields := strings.Fields(change[0])
+		if len(change) < 2 || len(fields) < 5 || fields[1] == submoduleMode {
+			continue
 		}
+		status := fields[4]
+		if (status[0] == 'R' || status[0] == 'C') && len(change) == 3 {
+			similarity, _ := strconv.Atoi(status[1:])
+			result = append(result, fileChange{path: change[2], oldPath: change[1], similarity: similarity, copied: status[0] == 'C', blob: fields[3]})
+			continue
+		}
+		result = append(result, fileChange{path: change[1], blob: fields[3]})
 	}
 	return result
 }
@@ -208,41 +465,20 @@ func (repo GitRepo) stagedVersionOfFile(file string) []byte {
 	return repo.executeRepoCommand("git", "show", ":"+file)
 }
 
-func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) []string {
-	allChanges := strings.Split(repo.fetchRawOutgoingDiff(oldCommit, newCommit), "\n")
-	var result []string
-	for _, c := range allChanges {
-		if len(c) != 0 {
-			result = append(result, c)
-		}
-	}
-	return result
-}
-
 func (repo *GitRepo) fetchStagedChanges() string {
-	return string(repo.executeRepoCommand("git", "diff", "--cached", "--name-status", "--diff-filter=ACM"))
+	return string(repo.executeRepoCommand("git", "diff", "--cached", "--raw", "--no-abbrev", "-M", "-C", "--diff-filter=ACMR"))
 }
 
-//Fetches the currently staged diff and filters the command output to get only the modified sections of the file
-func (repo *GitRepo) fetchStagedDiff(fileName string) []byte {
-	var result []byte
-	changes := strings.Split(string(repo.executeRepoCommand("git", "diff", "--staged", fileName)), "\n")
-	for _, c := range changes {
-		if !strings.HasPrefix(c, "+++") && !strings.HasPrefix(c, "---") && strings.HasPrefix(c, "+") {
-
-			result = append(result, strings.TrimPrefix(c, "+")...)
-			result = append(result, "\n"...)
-		}
+func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byte {
+	co, err := repo.runRepoCommand(commandName, args...)
+	if err != nil {
+		log.WithError(err).Fatal("Git command execution failed")
 	}
-	return result
-}
-
-func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) string {
-	gitRange := oldCommit + ".." + newCommit
-	return string(repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM"))
+	return co
 }
 
-func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byte {
+//runRepoCommand runs the command in the repo, returning an error carrying the output of the command if it fails
+func (repo GitRepo) runRepoCommand(commandName string, args ...string) ([]byte, error) {
 	log.WithFields(log.Fields{
 		"command": commandName,
 		"args":    args,
@@ -250,16 +486,14 @@ func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byt
 	result := exec.Command(commandName, args...)
 	result.Dir = repo.root
 	co, err := result.CombinedOutput()
-	logEntry := log.WithFields(log.Fields{
+	command := fmt.Sprintf("%s %s", commandName, strings.Join(args, " "))
+	if err != nil {
+		return co, fmt.Errorf("%s in %s failed: %v: %s", command, repo.root, err, strings.TrimSpace(string(co)))
+	}
+	log.WithFields(log.Fields{
 		"dir":     repo.root,
-		"command": fmt.Sprintf("%s %s", commandName, strings.Join(args, " ")),
+		"command": command,
 		"output":  string(co),
-		"error":   err,
-	})
-	if err == nil {
-		logEntry.Debug("Git command excuted successfully")
-	} else {
-		logEntry.Fatal("Git command execution failed")
-	}
-	return co
+	}).Debug("Git command excuted successfully")
+	return co, nil
 }
@@ -135,6 +135,111 @@ func TestStagedAdditionsShouldNotIncludeDeletedFiles(t *testing.T) {
 	assert.Len(t, stagedAdditions, 0)
 }
 
+func TestAdditionsWithinRangeDetectRenames(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.ExecCommand("git", "mv", "a.txt", "renamed.txt")
+	git.ExecCommand("git", "commit", "-m", "renamed a.txt")
+
+	additions := repo.AdditionsWithinRange("HEAD~1", "HEAD")
+	assert.Len(t, additions, 1)
+	assert.Equal(t, "renamed.txt", string(additions[0].Path))
+	assert.Equal(t, "a.txt", string(additions[0].OldPath))
+	assert.True(t, additions[0].IsPureRename(), "Expected an unchanged file moved with git mv to be a pure rename")
+}
+
+func TestStagedAdditionsDetectModifiedRenames(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	//the baseline files are too short for a changed copy to be reliably detected as a rename
+	git.CreateFileWithContents("long.txt", strings.Repeat("Unchanged line.\n", 20))
+	git.AddAndcommit("long.txt", "add long.txt")
+	git.ExecCommand("git", "mv", "long.txt", "renamed.txt")
+	git.AppendFileContent("renamed.txt", "\nNew content.\n")
+	git.Add("renamed.txt")
+
+	stagedAdditions := repo.GetDiffForStagedFiles()
+	assert.Len(t, stagedAdditions, 1)
+	assert.Equal(t, "long.txt", string(stagedAdditions[0].OldPath))
+	assert.False(t, stagedAdditions[0].IsPureRename(), "Expected a modified rename not to be a pure rename")
+	assert.True(t, strings.HasSuffix(string(stagedAdditions[0].Data), "New content.\n"))
+	assert.Equal(t, 2, strings.Count(string(stagedAdditions[0].Data), "\n"), "Expected only the lines changed in the renamed file")
+}
+
+func TestAdditionsWithinRangeReadTheCommittedVersionOfFiles(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("new.txt", "committed contents")
+	git.AddAndcommit("new.txt", "added new file")
+	git.OverwriteFileContent("new.txt", "working tree contents")
+
+	additions := repo.AdditionsWithinRange("HEAD~1", "HEAD")
+	assert.Len(t, additions, 1)
+	assert.Equal(t, "committed contents", string(additions[0].Data))
+	assert.Equal(t, len("committed contents"), additions[0].Size)
+}
+
+func TestAdditionsWithinRangeOnlyContainChangedLinesWithHunksForContext(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	git.CreateFileWithContents("lines.txt", "one\ntwo\nthree\nfour\nfive\n")
+	git.AddAndcommit("lines.txt", "added lines")
+	git.OverwriteFileContent("lines.txt", "one\ntwo\nTHREE\nfour\nfive\n")
+	git.AddAndcommit("lines.txt", "changed a line")
+
+	additions := repo.WithContextLines(1).AdditionsWithinRange("HEAD~1", "HEAD")
+	assert.Len(t, additions, 1)
+	assert.Equal(t, "THREE\n", string(additions[0].Data))
+	assert.Len(t, additions[0].Hunks, 1)
+	assert.Equal(t, 2, additions[0].Hunks[0].StartLine)
+	assert.Equal(t, []string{"two", "THREE", "four"}, additions[0].Hunks[0].Lines)
+	assert.Equal(t, []bool{false, true, false}, additions[0].Hunks[0].Added)
+
+	fullFileAdditions := repo.WithFullFiles(true).AdditionsWithinRange("HEAD~1", "HEAD")
+	assert.Equal(t, "one\ntwo\nTHREE\nfour\nfive\n", string(fullFileAdditions[0].Data))
+}
+
+func TestStagedAdditionsShouldNotIncludeSubmodules(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	addSubmodule(git, testLocation, "sub")
+
+	stagedAdditions := repo.StagedAdditions()
+	assert.Len(t, stagedAdditions, 1)
+	assert.Equal(t, ".gitmodules", string(stagedAdditions[0].Path))
+}
+
+func TestSubmoduleAdditionsWithinRangeArePrefixedWithTheSubmodulePath(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	addSubmodule(git, testLocation, "sub")
+	git.ExecCommand("git", "commit", "-m", "added submodule")
+
+	assert.Len(t, repo.AdditionsWithinRange("HEAD~1", "HEAD"), 1, "Expected only .gitmodules as the submodule content lives in another repository")
+	additions := repo.SubmoduleAdditionsWithinRange("HEAD~1", "HEAD")
+	assert.Len(t, additions, 2)
+	for _, addition := range additions {
+		assert.True(t, strings.HasPrefix(string(addition.Path), "sub/"), "Expected %s to be prefixed with the submodule path", addition.Path)
+	}
+}
+
+func TestRepoContainingResolvesTheRootAndCommonDirOfWorktrees(t *testing.T) {
+	cleanTestData()
+	git, repo := setupOriginAndClones(testLocation, cloneLocation)
+	worktreeLocation, _ := filepath.Abs(filepath.Join("data", "worktreeLocation"))
+	git.ExecCommand("git", "worktree", "add", "-b", "feature", worktreeLocation)
+
+	worktree := RepoContaining(filepath.Join(worktreeLocation, "alice", "bob"))
+	assert.Equal(t, worktreeLocation, worktree.Root())
+	assert.Equal(t, filepath.Join(repo.Root(), ".git", "worktrees", "worktreeLocation"), worktree.GitDir())
+	assert.Equal(t, filepath.Join(repo.Root(), ".git"), worktree.CommonDir())
+}
+
+func addSubmodule(git *git_testing.GitTesting, originLocation string, submodulePath string) {
+	originRoot, _ := filepath.Abs(originLocation)
+	git.ExecCommand("git", "-c", "protocol.file.allow=always", "submodule", "add", "-q", originRoot, submodulePath)
+}
+
 func setupOriginAndClones(originLocation, cloneLocation string) (*git_testing.GitTesting, GitRepo) {
 	origin := RepoLocatedAt(originLocation)
 	git := git_testing.Init(origin.root)
@@ -0,0 +1,83 @@
+package git_repo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//DefaultContextLines is the number of unchanged lines read around every changed section of a file
+const DefaultContextLines = 3
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -[0-9]+(?:,[0-9]+)? \+([0-9]+)(?:,[0-9]+)? @@`)
+
+//Hunk represents a changed section of a file, along with the unchanged lines surrounding it
+type Hunk struct {
+	//StartLine is the number of the first line of the hunk in the new version of the file
+	StartLine int
+	//Lines are the lines of the hunk as they read in the new version of the file
+	Lines []string
+	//Added states for every line whether it was added or modified, the other lines are unchanged context
+	Added []bool
+}
+
+//Text returns the lines of the hunk, including the context lines
+func (h Hunk) Text() string {
+	return strings.Join(h.Lines, "\n")
+}
+
+//AddsLineWithin answers whether any added line of the hunk overlaps the section of its Text between the start and end offsets
+func (h Hunk) AddsLineWithin(start int, end int) bool {
+	lineStart := 0
+	for i, line := range h.Lines {
+		lineEnd := lineStart + len(line)
+		if h.Added[i] && lineStart <= end && start <= lineEnd {
+			return true
+		}
+		lineStart = lineEnd + 1
+	}
+	return false
+}
+
+//parseUnifiedDiff reads the hunks of the diff of a single file and the content of the lines that were added to it.
+//It also answers whether git considered the file binary, in which case there are no hunks.
+func parseUnifiedDiff(diff string) ([]Hunk, []byte, bool) {
+	var hunks []Hunk
+	var added []byte
+	var current *Hunk
+	lastLineAdded := false
+	lastLineInNewVersion := false
+	for _, line := range strings.Split(diff, "\n") {
+		if current == nil && (strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch")) {
+			return nil, nil, true
+		}
+		if header := hunkHeaderPattern.FindStringSubmatch(line); header != nil {
+			startLine, _ := strconv.Atoi(header[1])
+			hunks = append(hunks, Hunk{StartLine: startLine})
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+		if current == nil || len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '+', ' ':
+			current.Lines = append(current.Lines, line[1:])
+			current.Added = append(current.Added, line[0] == '+')
+			lastLineAdded = line[0] == '+'
+			lastLineInNewVersion = true
+			if lastLineAdded {
+				added = append(added, line[1:]+"\n"...)
+			}
+		case '-':
+			lastLineAdded = false
+			lastLineInNewVersion = false
+		case '\\':
+			//"\ No newline at end of file" refers to the line before it
+			if lastLineAdded && lastLineInNewVersion {
+				added = added[:len(added)-1]
+			}
+		}
+	}
+	return hunks, added, false
+}
@@ -0,0 +1,107 @@
+package git_repo
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	submoduleMode = "160000"
+	emptySha      = "0000000000000000000000000000000000000000"
+	//EmptyTreeSha is the hash of the empty tree, which is diffed against to read every file of a commit
+	EmptyTreeSha = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+)
+
+//SubmoduleChange represents a submodule whose recorded commit changed between two commits of the superproject
+type SubmoduleChange struct {
+	Path      string
+	OldCommit string
+	NewCommit string
+}
+
+//IsNew states whether the submodule was added by the change rather than moved to another commit
+func (c SubmoduleChange) IsNew() bool {
+	return c.OldCommit == emptySha
+}
+
+//Submodule returns the repo of the submodule checked out at the supplied relative path
+func (repo GitRepo) Submodule(submodulePath string) GitRepo {
+	repo.root = filepath.Join(repo.root, submodulePath)
+	return repo
+}
+
+//CheckedOutSubmodules returns the relative paths of the submodules of the repo that are checked out in its working tree
+func (repo GitRepo) CheckedOutSubmodules() []string {
+	var result []string
+	for _, entry := range strings.Split(string(repo.executeRepoCommand("git", "ls-files", "--stage")), "\n") {
+		fields := strings.SplitN(entry, "\t", 2)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], submoduleMode+" ") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repo.root, fields[1], ".git")); err == nil {
+			result = append(result, fields[1])
+		}
+	}
+	return result
+}
+
+//HasCommit states whether the commit is present in the object database of the repo
+func (repo GitRepo) HasCommit(commit string) bool {
+	command := exec.Command("git", "cat-file", "-e", commit+"^{commit}")
+	command.Dir = repo.root
+	return command.Run() == nil
+}
+
+//ChangedSubmodules returns the submodules whose recorded commit was added or changed in the given commit range
+func (repo GitRepo) ChangedSubmodules(oldCommit string, newCommit string) []SubmoduleChange {
+	rawDiff := repo.executeRepoCommand("git", "diff", oldCommit+".."+newCommit, "--raw", "--no-abbrev", "--diff-filter=AM")
+	var result []SubmoduleChange
+	for _, c := range strings.Split(string(rawDiff), "\n") {
+		change := strings.SplitN(c, "\t", 2)
+		if len(change) != 2 {
+			continue
+		}
+		fields := strings.Fields(change[0])
+		if len(fields) < 4 || fields[1] != submoduleMode {
+			continue
+		}
+		result = append(result, SubmoduleChange{Path: change[1], OldCommit: fields[2], NewCommit: fields[3]})
+	}
+	return result
+}
+
+//SubmoduleAdditionsWithinRange returns the additions of the new commits of every checked out submodule changed in the given commit range, recursing into nested submodules.
+//The paths of the additions are prefixed with the path of their submodule. Submodule commits that have not been fetched are skipped.
+func (repo GitRepo) SubmoduleAdditionsWithinRange(oldCommit string, newCommit string) []Addition {
+	var result []Addition
+	for _, change := range repo.ChangedSubmodules(oldCommit, newCommit) {
+		submodule := repo.Submodule(change.Path)
+		if _, err := os.Stat(filepath.Join(submodule.root, ".git")); err != nil || !submodule.HasCommit(change.NewCommit) {
+			log.WithFields(log.Fields{
+				"submodule": change.Path,
+				"commit":    change.NewCommit,
+			}).Info("Skipping submodule as its commit is not available locally.")
+			continue
+		}
+		submoduleOldCommit := change.OldCommit
+		if change.IsNew() || !submodule.HasCommit(submoduleOldCommit) {
+			submoduleOldCommit = EmptyTreeSha
+		}
+		additions := append(submodule.AdditionsWithinRange(submoduleOldCommit, change.NewCommit), submodule.SubmoduleAdditionsWithinRange(submoduleOldCommit, change.NewCommit)...)
+		for _, addition := range additions {
+			result = append(result, addition.InSubmodule(change.Path))
+		}
+	}
+	return result
+}
+
+//InSubmodule returns the addition with its path prefixed by the path of the submodule it was found in
+func (a Addition) InSubmodule(submodulePath string) Addition {
+	a.Path = FilePath(path.Join(submodulePath, string(a.Path)))
+	return a
+}
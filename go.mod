@@ -1,17 +1,23 @@
 module talisman
 
+go 1.27.1
+
 require (
 	github.com/Sirupsen/logrus v0.0.0-20151204141443-446d1c146faa
 	github.com/bmatcuk/doublestar v1.1.1
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/drhodes/golorem v0.0.0-20120624033213-6e38d8d5e455
+	github.com/olekukonko/tablewriter v0.0.1
+	github.com/spf13/pflag v1.0.3
+	github.com/stretchr/testify v0.0.0-20151208002404-e3a8ff8ce365
+	gopkg.in/yaml.v2 v2.2.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/mattn/go-runewidth v0.0.4 // indirect
 	github.com/mitchellh/gox v0.4.0 // indirect
 	github.com/mitchellh/iochan v1.0.0 // indirect
-	github.com/olekukonko/tablewriter v0.0.1
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/spf13/pflag v1.0.3
-	github.com/stretchr/testify v0.0.0-20151208002404-e3a8ff8ce365
 	golang.org/x/sys v0.0.0-20181031143558-9b800f95dbbc // indirect
-	gopkg.in/yaml.v2 v2.2.1
+	gopkg.in/check.v1 v0.0.0-20161208181325-20d25e280405 // indirect
 )
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"talisman/detector"
+	"talisman/git_repo"
+	"talisman/utility"
+
+	log "github.com/Sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//InteractiveRemediation walks the user through each failing file of a run and collects how every failure should be resolved.
+//Nothing is written to the repository until all the failures have been walked through, so aborting leaves the repository untouched.
+type InteractiveRemediation struct {
+	repo git_repo.GitRepo
+	in   *bufio.Reader
+	out  io.Writer
+
+	ignores    []detector.FileIgnoreConfig
+	suppressed []string
+	unstaged   []string
+}
+
+//NewInteractiveRemediation returns an InteractiveRemediation that prompts on out and reads the answers from in
+func NewInteractiveRemediation(repo git_repo.GitRepo, in io.Reader, out io.Writer) *InteractiveRemediation {
+	return &InteractiveRemediation{repo: repo, in: bufio.NewReader(in), out: out}
+}
+
+//openTerminal returns the terminal the process is attached to, even when git has redirected the standard streams of the hook
+func openTerminal() (*os.File, bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, false
+	}
+	info, err := tty.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		tty.Close()
+		return nil, false
+	}
+	return tty, true
+}
+
+//Remediate prompts for every file that failed detection and applies the chosen resolutions.
+//It returns false if the user aborted, in which case nothing is changed.
+func (ir *InteractiveRemediation) Remediate(results *detector.DetectionResults) bool {
+	for _, resultDetails := range results.Results {
+		if len(resultDetails.FailureList) == 0 {
+			continue
+		}
+		if !ir.remediateFile(string(resultDetails.Filename), resultDetails.FailureList) {
+			fmt.Fprintln(ir.out, "Aborted. No changes were made.")
+			return false
+		}
+	}
+	ir.apply()
+	return true
+}
+
+func (ir *InteractiveRemediation) remediateFile(filePath string, failures []detector.Details) bool {
+	fmt.Fprintf(ir.out, "\n%s\n", filePath)
+	for _, failure := range failures {
+		fmt.Fprintf(ir.out, "  - %s [%s]\n", failure.Message, failure.Fingerprint)
+	}
+	for {
+		answer, ok := ir.ask("[i]gnore with a reason, [s]uppress inline, [u]nstage, s[k]ip, [a]bort? ")
+		if !ok {
+			return false
+		}
+		switch answer {
+		case "i":
+			reason := ""
+			for reason == "" {
+				if reason, ok = ir.ask("Reason: "); !ok {
+					return false
+				}
+			}
+			ir.ignores = append(ir.ignores, detector.FileIgnoreConfig{
+				FileName:        filePath,
+				Checksum:        ir.stagedChecksum(filePath),
+				IgnoreDetectors: []string{},
+				Reason:          reason,
+			})
+			return true
+		case "s":
+			ir.suppressed = append(ir.suppressed, filePath)
+			return true
+		case "u":
+			ir.unstaged = append(ir.unstaged, filePath)
+			return true
+		case "k":
+			return true
+		case "a":
+			return false
+		}
+	}
+}
+
+//ask returns the answer to the question, and false once there are no answers left to read, e.g. when the terminal is closed
+func (ir *InteractiveRemediation) ask(question string) (string, bool) {
+	fmt.Fprint(ir.out, question)
+	answer, err := ir.in.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && answer == "" {
+		return "", false
+	}
+	return answer, true
+}
+
+func (ir *InteractiveRemediation) apply() {
+	for _, filePath := range ir.suppressed {
+		ir.suppressInline(filePath)
+	}
+	for _, filePath := range ir.unstaged {
+		log.Debugf("Unstaging %s", filePath)
+		ir.repo.Unstage(filePath)
+		fmt.Fprintf(ir.out, "Unstaged %s\n", filePath)
+	}
+	if len(ir.ignores) > 0 {
+		ir.writeIgnores()
+	}
+}
+
+//stagedChecksum returns the checksum of the staged version of the file, which is what the pre-commit hook compares the ignores against
+func (ir *InteractiveRemediation) stagedChecksum(filePath string) string {
+	data, err := ir.repo.ReadStagedFile(filePath)
+	if err != nil {
+		return utility.CollectiveSHA256Hash([]string{filePath})
+	}
+	return utility.CollectiveSHA256HashOfContents([]string{filePath}, [][]byte{data})
+}
+
+//suppressInline marks the flagged lines of the staged version of the file and stages the result without touching any other staged or unstaged change.
+//The same lines are marked in the working tree wherever they are still unchanged there, so that staging the file again keeps the markers.
+func (ir *InteractiveRemediation) suppressInline(filePath string) {
+	staged, err := ir.repo.ReadStagedFile(filePath)
+	if err != nil {
+		fmt.Fprintf(ir.out, "Unable to read the staged version of %s: %s\n", filePath, err)
+		return
+	}
+	lineIndices := detector.LinesToSuppress(git_repo.NewAddition(filePath, staged))
+	if len(lineIndices) == 0 {
+		fmt.Fprintf(ir.out, "No line of %s can be suppressed inline, consider ignoring it instead\n", filePath)
+		return
+	}
+	lines := strings.Split(string(staged), "\n")
+	suppressed := map[string]bool{}
+	for _, i := range lineIndices {
+		suppressed[lines[i]] = true
+		lines[i] = detector.SuppressLine(filePath, lines[i])
+	}
+	if err := ir.repo.StageContent(filePath, []byte(strings.Join(lines, "\n"))); err != nil {
+		fmt.Fprintf(ir.out, "Unable to stage %s: %s\n", filePath, err)
+		return
+	}
+	if data, err := ir.repo.ReadRepoFile(filePath); err == nil {
+		workingLines := strings.Split(string(data), "\n")
+		for i, line := range workingLines {
+			if suppressed[line] {
+				workingLines[i] = detector.SuppressLine(filePath, line)
+			}
+		}
+		if err := ir.repo.WriteRepoFile(filePath, []byte(strings.Join(workingLines, "\n"))); err != nil {
+			fmt.Fprintf(ir.out, "Unable to write %s: %s\n", filePath, err)
+		}
+	}
+	fmt.Fprintf(ir.out, "Suppressed %d line(s) of %s and re-staged it\n", len(lineIndices), filePath)
+}
+
+func (ir *InteractiveRemediation) writeIgnores() {
+	contents, _ := ir.repo.ReadRepoFileOrNothing(detector.DefaultRCFileName)
+	talismanRCIgnore := detector.NewTalismanRCIgnore(contents)
+	for _, ignore := range ir.ignores {
+		talismanRCIgnore.AddFileIgnoreConfig(ignore)
+	}
+	m, _ := yaml.Marshal(&talismanRCIgnore)
+	if err := ir.repo.WriteRepoFile(detector.DefaultRCFileName, m); err != nil {
+		fmt.Fprintf(ir.out, "Unable to write %s: %s\n", detector.DefaultRCFileName, err)
+		return
+	}
+	fmt.Fprintf(ir.out, "Updated %s with %d ignore(s), remember to commit it\n", detector.DefaultRCFileName, len(ir.ignores))
+}
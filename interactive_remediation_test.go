@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"talisman/detector"
+	"talisman/git_repo"
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteractivelyIgnoringAFailureWritesTalismanRCAndPasses(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+
+		assert.Equal(t, 0, runInteractiveRemediation(git, "i\nknown test fixture\n"), "Expected the re-run to pass once the file is ignored")
+		assert.Contains(t, string(git.FileContents(".talismanrc")), "reason: known test fixture")
+	})
+}
+
+func TestInteractivelySuppressingAFailureMarksTheLineAndPasses(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("sample.txt", "password=somepassword \n")
+		git.Add("*")
+
+		assert.Equal(t, 0, runInteractiveRemediation(git, "s\n"), "Expected the re-run to pass once the line is suppressed")
+		assert.Equal(t, "password=somepassword # talisman:ignore\n", string(git.FileContents("sample.txt")))
+	})
+}
+
+func TestInteractivelySuppressingAFailureLeavesUnstagedChangesUnstaged(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("sample.txt", "password=somepassword\n")
+		git.Add("*")
+		git.AppendFileContent("sample.txt", "unstaged line\n")
+
+		assert.Equal(t, 0, runInteractiveRemediation(git, "s\n"), "Expected the re-run to pass once the line is suppressed")
+		staged, _ := git_repo.RepoLocatedAt(git.GetRoot()).ReadStagedFile("sample.txt")
+		assert.Equal(t, "password=somepassword # talisman:ignore\n", string(staged))
+		assert.Equal(t, "password=somepassword # talisman:ignore\nunstaged line\n", string(git.FileContents("sample.txt")))
+	})
+}
+
+func TestInteractivelyUnstagingAFailurePasses(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+
+		assert.Equal(t, 0, runInteractiveRemediation(git, "u\n"), "Expected the re-run to pass once the file is unstaged")
+	})
+}
+
+func TestAbortingInteractiveRemediationFailsWithoutChanges(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+
+		assert.Equal(t, 1, runInteractiveRemediation(git, "a\n"), "Expected an aborted remediation to fail")
+		_, err := os.Stat(git.GetRoot() + "/.talismanrc")
+		assert.True(t, os.IsNotExist(err), "Expected no .talismanrc to be written on abort")
+	})
+}
+
+func TestInteractiveRemediationAbortsWhenTheAnswersRunOut(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+
+		assert.Equal(t, 1, runInteractiveRemediation(git, "i\n"), "Expected the remediation to abort instead of asking for a reason forever")
+		_, err := os.Stat(git.GetRoot() + "/.talismanrc")
+		assert.True(t, os.IsNotExist(err), "Expected no .talismanrc to be written on abort")
+	})
+}
+
+func TestInteractiveRemediationWritesMachineReadableResultsOnceForTheFinalRun(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.Add("*")
+		stdout, _ := ioutil.TempFile("", "talisman-stdout")
+		defer os.Remove(stdout.Name())
+		realStdout := os.Stdout
+		os.Stdout = stdout
+		wd, _ := os.Getwd()
+		os.Chdir(git.GetRoot())
+		preCommitHook := NewPreCommitHook()
+		remediation := NewInteractiveRemediation(git_repo.RepoLocatedAt(git.GetRoot()), strings.NewReader("u\n"), ioutil.Discard)
+		exitStatus := NewRunner(preCommitHook.GetRepoAdditions()).OutputFormat("json").RunInteractively(remediation, preCommitHook.GetRepoAdditions)
+		os.Chdir(wd)
+		os.Stdout = realStdout
+		stdout.Close()
+
+		assert.Equal(t, 0, exitStatus, "Expected the re-run to pass once the file is unstaged")
+		output, _ := ioutil.ReadFile(stdout.Name())
+		decoder := json.NewDecoder(bytes.NewReader(output))
+		var results detector.DetectionResults
+		assert.NoError(t, decoder.Decode(&results), "Expected the results on stdout as json")
+		assert.False(t, results.HasFailures(), "Expected the results of the re-run")
+		assert.False(t, decoder.More(), "Expected the results to be written only once")
+	})
+}
+
+func runInteractiveRemediation(git *git_testing.GitTesting, answers string) int {
+	wd, _ := os.Getwd()
+	os.Chdir(git.GetRoot())
+	defer func() { os.Chdir(wd) }()
+	preCommitHook := NewPreCommitHook()
+	remediation := NewInteractiveRemediation(git_repo.RepoLocatedAt(git.GetRoot()), strings.NewReader(answers), ioutil.Discard)
+	return NewRunner(preCommitHook.GetRepoAdditions()).RunInteractively(remediation, preCommitHook.GetRepoAdditions)
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"talisman/detector"
+	"talisman/git_repo"
+	"talisman/utility"
+
+	log "github.com/Sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	lspSource = "talisman"
+
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+
+	lspTextDocumentSyncFull = 1
+
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+)
+
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnosticData struct {
+	Category    string `json:"category"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange          `json:"range"`
+	Severity int               `json:"severity"`
+	Code     string            `json:"code,omitempty"`
+	Source   string            `json:"source"`
+	Message  string            `json:"message"`
+	Data     lspDiagnosticData `json:"data"`
+}
+
+type lspTextDocument struct {
+	URI     string `json:"uri"`
+	Text    string `json:"text,omitempty"`
+	Version int    `json:"version,omitempty"`
+}
+
+type lspDidChangeParams struct {
+	TextDocument   lspTextDocument `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type lspCodeActionParams struct {
+	TextDocument lspTextDocument `json:"textDocument"`
+	Range        lspRange        `json:"range"`
+	Context      struct {
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	} `json:"context"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCodeAction struct {
+	Title       string          `json:"title"`
+	Kind        string          `json:"kind"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+	Edit        interface{}     `json:"edit"`
+}
+
+//LanguageServer speaks the Language Server Protocol over a pair of streams, so that editors can show what the content detectors find while files are edited.
+//Documents are scanned as a whole on every change, with the .talismanrc of the repository they belong to, exactly like the hooks would scan them.
+type LanguageServer struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]string
+}
+
+//NewLanguageServer returns a LanguageServer reading requests from in and writing responses and notifications to out
+func NewLanguageServer(in io.Reader, out io.Writer) *LanguageServer {
+	return &LanguageServer{in: bufio.NewReader(in), out: out, documents: map[string]string{}}
+}
+
+//Serve handles messages until the client sends the exit notification or closes the input
+func (s *LanguageServer) Serve() error {
+	for {
+		message, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if message.Method == "exit" {
+			return nil
+		}
+		s.handle(message)
+	}
+}
+
+func (s *LanguageServer) handle(message *lspMessage) {
+	log.WithFields(log.Fields{"method": message.Method}).Debug("Handling language server message")
+	switch message.Method {
+	case "initialize":
+		s.respond(message, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   lspTextDocumentSyncFull,
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "talisman", "version": Version},
+		}, nil)
+	case "shutdown":
+		s.respond(message, nil, nil)
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if json.Unmarshal(message.Params, &params) == nil {
+			s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params lspDidChangeParams
+		if json.Unmarshal(message.Params, &params) == nil && len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didSave":
+		//the .talismanrc is read from disk, so saving it changes the results of every open document
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if json.Unmarshal(message.Params, &params) == nil && filepath.Base(uriPath(params.TextDocument.URI)) == detector.DefaultRCFileName {
+			for uri, text := range s.documents {
+				s.publishDiagnostics(uri, text)
+			}
+		}
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if json.Unmarshal(message.Params, &params) == nil {
+			delete(s.documents, params.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": params.TextDocument.URI, "diagnostics": []lspDiagnostic{}})
+		}
+	case "textDocument/codeAction":
+		var params lspCodeActionParams
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			s.respond(message, nil, &lspError{lspInvalidParams, err.Error()})
+			return
+		}
+		s.respond(message, s.codeActions(params), nil)
+	default:
+		if message.ID != nil {
+			s.respond(message, nil, &lspError{lspMethodNotFound, "method not supported: " + message.Method})
+		}
+	}
+}
+
+func (s *LanguageServer) update(uri string, text string) {
+	s.documents[uri] = text
+	s.publishDiagnostics(uri, text)
+}
+
+func (s *LanguageServer) publishDiagnostics(uri string, text string) {
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": s.diagnose(uri, text)})
+}
+
+//diagnose runs the content detectors against the text and locates every secret they report in it
+func (s *LanguageServer) diagnose(uri string, text string) []lspDiagnostic {
+	repo, relativePath := documentLocation(uri)
+	rc := detector.ReadConfigFromRCFile(repo.ReadRepoFileOrNothing, log.StandardLogger())
+	addition := git_repo.NewAddition(relativePath, []byte(text))
+
+	//the secrets are needed in full to locate them, they are redacted again in the messages
+	results := detector.NewDetectionResults()
+	results.SetRedactionPolicy(detector.RedactionPolicy{ShowSecrets: true})
+	chain := detector.NewChain().AddDetector(detector.NewFileContentDetector()).AddDetector(detector.NewPatternDetector())
+	chain.Test([]git_repo.Addition{addition}, rc, results)
+
+	diagnostics := []lspDiagnostic{}
+	for _, resultDetails := range results.Results {
+		for _, details := range resultDetails.FailureList {
+			diagnostics = append(diagnostics, locate(text, details, lspSeverityError, rc.Redaction)...)
+		}
+		for _, details := range resultDetails.WarningList {
+			diagnostics = append(diagnostics, locate(text, details, lspSeverityWarning, rc.Redaction)...)
+		}
+	}
+	return diagnostics
+}
+
+//locate returns a diagnostic for every occurrence of the secret of the details on a line that is not suppressed.
+//Details without a secret, or whose secret cannot be located, are reported on the first line.
+func locate(text string, details detector.Details, severity int, redaction detector.RedactionPolicy) []lspDiagnostic {
+	diagnostic := lspDiagnostic{
+		Severity: severity,
+		Code:     details.RuleID,
+		Source:   lspSource,
+		Message:  details.Message,
+		Data:     lspDiagnosticData{Category: details.Category, Fingerprint: details.Fingerprint},
+	}
+	lines := strings.Split(text, "\n")
+	var result []lspDiagnostic
+	if details.Secret != nil && details.Secret.Preview != "" {
+		secret := details.Secret.Preview
+		diagnostic.Message = strings.Replace(details.Message, details.Secret.String(), redaction.Redact(secret).String(), -1)
+		for offset := 0; ; {
+			index := strings.Index(text[offset:], secret)
+			if index == -1 {
+				break
+			}
+			start := offset + index
+			offset = start + len(secret)
+			diagnostic.Range = lspRange{Start: position(text, start), End: position(text, offset)}
+			if !detector.IsSuppressedLine(lines[diagnostic.Range.Start.Line]) {
+				result = append(result, diagnostic)
+			}
+		}
+	}
+	if len(result) == 0 {
+		diagnostic.Range = lspRange{End: lspPosition{Character: utf16Length(lines[0])}}
+		result = append(result, diagnostic)
+	}
+	return result
+}
+
+//position converts a byte offset into the text into a line and a character offset counted in UTF-16 code units, as the protocol requires
+func position(text string, offset int) lspPosition {
+	line := strings.Count(text[:offset], "\n")
+	lineStart := strings.LastIndex(text[:offset], "\n") + 1
+	return lspPosition{Line: line, Character: utf16Length(text[lineStart:offset])}
+}
+
+func utf16Length(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func (s *LanguageServer) codeActions(params lspCodeActionParams) []lspCodeAction {
+	text, ok := s.documents[params.TextDocument.URI]
+	actions := []lspCodeAction{}
+	if !ok {
+		return actions
+	}
+	repo, relativePath := documentLocation(params.TextDocument.URI)
+	lines := strings.Split(text, "\n")
+	var diagnostics []lspDiagnostic
+	for _, diagnostic := range params.Context.Diagnostics {
+		if diagnostic.Source != lspSource || diagnostic.Range.Start.Line >= len(lines) {
+			continue
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		line := lines[diagnostic.Range.Start.Line]
+		if !detector.IsSuppressedLine(line) {
+			edit := lspTextEdit{
+				Range:   lspRange{Start: lspPosition{Line: diagnostic.Range.Start.Line}, End: lspPosition{Line: diagnostic.Range.Start.Line, Character: utf16Length(line)}},
+				NewText: detector.SuppressLine(relativePath, line),
+			}
+			actions = append(actions, lspCodeAction{
+				Title:       "Suppress with " + detector.InlineSuppressionMarker,
+				Kind:        "quickfix",
+				Diagnostics: []lspDiagnostic{diagnostic},
+				Edit:        map[string]interface{}{"changes": map[string][]lspTextEdit{params.TextDocument.URI: {edit}}},
+			})
+		}
+	}
+	if len(diagnostics) > 0 {
+		if edit, ok := ignoreEdit(repo, relativePath, text); ok {
+			actions = append(actions, lspCodeAction{
+				Title:       fmt.Sprintf("Ignore this version of %s in %s", relativePath, detector.DefaultRCFileName),
+				Kind:        "quickfix",
+				Diagnostics: diagnostics,
+				Edit:        edit,
+			})
+		}
+	}
+	return actions
+}
+
+//ignoreEdit returns the workspace edit creating the .talismanrc of the repository if needed and adding the checksum of the current text of the document to it
+func ignoreEdit(repo git_repo.GitRepo, relativePath string, text string) (interface{}, bool) {
+	contents, _ := repo.ReadRepoFileOrNothing(detector.DefaultRCFileName)
+	if _, err := detector.ParseTalismanRCIgnore(contents); err != nil {
+		return nil, false
+	}
+	checksum := utility.CollectiveSHA256HashOfContents([]string{relativePath}, [][]byte{[]byte(text)})
+	edit, ok := fileIgnoreTextEdit(string(contents), relativePath, checksum)
+	if !ok {
+		return nil, false
+	}
+	rcURI := (&url.URL{Scheme: "file", Path: filepath.Join(repo.Root(), detector.DefaultRCFileName)}).String()
+	return map[string]interface{}{
+		"documentChanges": []interface{}{
+			map[string]interface{}{"kind": "create", "uri": rcURI, "options": map[string]bool{"ignoreIfExists": true}},
+			map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": rcURI, "version": nil},
+				"edits":        []lspTextEdit{edit},
+			},
+		},
+	}, true
+}
+
+var (
+	fileIgnoreConfigKey = regexp.MustCompile(`^fileignoreconfig\s*:(.*)$`)
+	yamlListItem        = regexp.MustCompile(`^(\s*)-(\s|$)`)
+	filenameKey         = regexp.MustCompile(`^(\s*)(-\s+)?filename\s*:\s*(.*)$`)
+	checksumKey         = regexp.MustCompile(`^(\s*)(-\s+)?checksum\s*:`)
+)
+
+//fileIgnoreTextEdit returns the edit of the .talismanrc contents that sets the checksum of the path, touching only the entry of the path
+//so that the comments, order and formatting of the rest of the file are kept. The checksum of an existing entry for the path is replaced,
+//otherwise an entry is appended to the fileignoreconfig list, which is added to the end of the file if there is none.
+//It returns false for a fileignoreconfig list written in flow style, which cannot be edited line by line.
+func fileIgnoreTextEdit(contents string, relativePath string, checksum string) (lspTextEdit, bool) {
+	lines := strings.Split(contents, "\n")
+	quotedPath := yamlScalar(relativePath)
+	key := -1
+	for i, line := range lines {
+		if match := fileIgnoreConfigKey.FindStringSubmatch(line); match != nil {
+			if value := strings.TrimSpace(match[1]); value != "" && !strings.HasPrefix(value, "#") {
+				return lspTextEdit{}, false
+			}
+			key = i
+		}
+	}
+	if key == -1 {
+		entry := "fileignoreconfig:\n- filename: " + quotedPath + "\n  checksum: " + checksum + "\n  ignore_detectors: []\n"
+		if contents != "" && !strings.HasSuffix(contents, "\n") {
+			entry = "\n" + entry
+		}
+		return insertAt(len(lines)-1, utf16Length(lines[len(lines)-1]), entry), true
+	}
+	//the list ends before the first line that is neither blank, a comment nor indented
+	end := key + 1
+	itemIndent := ""
+	for i := key + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(lines[i], " ") && !strings.HasPrefix(lines[i], "\t") && !yamlListItem.MatchString(lines[i]) {
+			break
+		}
+		if match := yamlListItem.FindStringSubmatch(lines[i]); match != nil && end == key+1 {
+			itemIndent = match[1]
+		}
+		end = i + 1
+	}
+	for i := key + 1; i < end; i++ {
+		match := filenameKey.FindStringSubmatch(lines[i])
+		if match == nil || yamlValue(match[3]) != relativePath {
+			continue
+		}
+		itemStart, itemEnd := i, i+1
+		for match[2] == "" && itemStart > key+1 && !yamlListItem.MatchString(lines[itemStart]) {
+			itemStart--
+		}
+		for itemEnd < end && !yamlListItem.MatchString(lines[itemEnd]) {
+			itemEnd++
+		}
+		for j := itemStart; j < itemEnd; j++ {
+			if checksumMatch := checksumKey.FindStringSubmatch(lines[j]); checksumMatch != nil {
+				prefix := checksumMatch[0]
+				return lspTextEdit{
+					Range:   lspRange{Start: lspPosition{Line: j, Character: utf16Length(prefix)}, End: lspPosition{Line: j, Character: utf16Length(lines[j])}},
+					NewText: " " + checksum,
+				}, true
+			}
+		}
+		indent := strings.Repeat(" ", len(match[1])+len(match[2]))
+		return insertAt(i+1, 0, indent+"checksum: "+checksum+"\n"), true
+	}
+	entry := itemIndent + "- filename: " + quotedPath + "\n" + itemIndent + "  checksum: " + checksum + "\n" + itemIndent + "  ignore_detectors: []\n"
+	if end == len(lines) {
+		//the list runs up to the end of a file without a final newline
+		return insertAt(end-1, utf16Length(lines[end-1]), "\n"+strings.TrimSuffix(entry, "\n")), true
+	}
+	return insertAt(end, 0, entry), true
+}
+
+func insertAt(line int, character int, text string) lspTextEdit {
+	position := lspPosition{Line: line, Character: character}
+	return lspTextEdit{Range: lspRange{Start: position, End: position}, NewText: text}
+}
+
+//yamlScalar returns the value as a YAML scalar, quoted if needed
+func yamlScalar(value string) string {
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return strings.TrimSuffix(string(encoded), "\n")
+}
+
+//yamlValue returns the string a YAML scalar, possibly quoted and followed by a comment, stands for
+func yamlValue(scalar string) string {
+	var value string
+	if err := yaml.Unmarshal([]byte(scalar), &value); err != nil {
+		return strings.TrimSpace(scalar)
+	}
+	return value
+}
+
+//documentLocation returns the repository containing the document and the path of the document relative to its root
+func documentLocation(uri string) (git_repo.GitRepo, string) {
+	documentPath := uriPath(uri)
+	//documents that were not saved yet may be in directories that do not exist yet either
+	directory := filepath.Dir(documentPath)
+	for {
+		if _, err := os.Stat(directory); err == nil || filepath.Dir(directory) == directory {
+			break
+		}
+		directory = filepath.Dir(directory)
+	}
+	repo := git_repo.RepoContaining(directory)
+	relativePath, err := filepath.Rel(repo.Root(), documentPath)
+	if err != nil {
+		relativePath = filepath.Base(documentPath)
+	}
+	return repo, filepath.ToSlash(relativePath)
+}
+
+func uriPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return parsed.Path
+}
+
+func (s *LanguageServer) read() (*lspMessage, error) {
+	headers, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %v", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	var message lspMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("malformed message: %v", err)
+	}
+	return &message, nil
+}
+
+func (s *LanguageServer) write(message lspMessage) {
+	message.JSONRPC = "2.0"
+	body, err := json.Marshal(message)
+	if err != nil {
+		log.Errorf("Unable to encode language server message: %v", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *LanguageServer) respond(request *lspMessage, result interface{}, err *lspError) {
+	if err == nil && result == nil {
+		//the protocol requires the result to be present, if null, unless there is an error
+		result = json.RawMessage("null")
+	}
+	s.write(lspMessage{ID: request.ID, Result: result, Error: err})
+}
+
+func (s *LanguageServer) notify(method string, params interface{}) {
+	encoded, _ := json.Marshal(params)
+	s.write(lspMessage{Method: method, Params: encoded})
+}
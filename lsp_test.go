@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"talisman/detector"
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const awsSecretAccessKeyLine = "aws_secret_access_key: wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+
+func lspRequest(id int, method string, params interface{}) string {
+	encoded, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(encoded), encoded)
+}
+
+func lspNotification(method string, params interface{}) string {
+	encoded, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(encoded), encoded)
+}
+
+func runLanguageServer(t *testing.T, messages ...string) []lspMessage {
+	var output bytes.Buffer
+	assert.Nil(t, NewLanguageServer(strings.NewReader(strings.Join(messages, "")), &output).Serve())
+	server := NewLanguageServer(&output, nil)
+	var result []lspMessage
+	for {
+		message, err := server.read()
+		if err != nil {
+			return result
+		}
+		result = append(result, *message)
+	}
+}
+
+func publishedDiagnostics(messages []lspMessage) [][]lspDiagnostic {
+	var result [][]lspDiagnostic
+	for _, message := range messages {
+		if message.Method == "textDocument/publishDiagnostics" {
+			var params struct {
+				Diagnostics []lspDiagnostic `json:"diagnostics"`
+			}
+			json.Unmarshal(message.Params, &params)
+			result = append(result, params.Diagnostics)
+		}
+	}
+	return result
+}
+
+func TestLanguageServerShouldPublishDiagnosticsOfOpenedAndChangedDocuments(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		uri := "file://" + filepath.Join(git.GetRoot(), "config", "settings.yml")
+		text := "region: eu-west-1\n" + awsSecretAccessKeyLine + "\n"
+		messages := runLanguageServer(t,
+			lspRequest(1, "initialize", map[string]interface{}{}),
+			lspNotification("textDocument/didOpen", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri, "text": text}}),
+			lspNotification("textDocument/didChange", map[string]interface{}{
+				"textDocument":   map[string]interface{}{"uri": uri},
+				"contentChanges": []map[string]string{{"text": "region: eu-west-1\n" + awsSecretAccessKeyLine + " # talisman:ignore\n"}},
+			}),
+			lspRequest(2, "shutdown", nil),
+			lspNotification("exit", nil),
+		)
+
+		assert.Contains(t, string(mustMarshal(messages[0].Result)), `"textDocumentSync":1`)
+		diagnostics := publishedDiagnostics(messages)
+		assert.Len(t, diagnostics, 2)
+		var diagnostic lspDiagnostic
+		for _, published := range diagnostics[0] {
+			if published.Code == detector.Base64RuleID {
+				diagnostic = published
+			}
+		}
+		assert.Equal(t, lspRange{Start: lspPosition{Line: 1, Character: 23}, End: lspPosition{Line: 1, Character: len(awsSecretAccessKeyLine)}}, diagnostic.Range)
+		assert.Equal(t, detector.Base64RuleID, diagnostic.Code)
+		assert.Equal(t, "filecontent", diagnostic.Data.Category)
+		assert.NotContains(t, diagnostic.Message, "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "Expected the secret to be redacted")
+		assert.Empty(t, diagnostics[1], "Expected the suppressed line not to be reported")
+	})
+}
+
+func TestLanguageServerShouldHonourTheTalismanRCOfTheRepository(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: config/settings.yml\n  ignore_detectors: [filecontent]\n")
+		uri := "file://" + filepath.Join(git.GetRoot(), "config", "settings.yml")
+		messages := runLanguageServer(t,
+			lspNotification("textDocument/didOpen", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri, "text": awsSecretAccessKeyLine}}),
+		)
+
+		assert.Equal(t, [][]lspDiagnostic{{}}, publishedDiagnostics(messages))
+	})
+}
+
+func TestLanguageServerShouldOfferCodeActionsToSuppressOrIgnoreFindings(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		uri := "file://" + filepath.Join(git.GetRoot(), "settings.yml")
+		diagnostic := lspDiagnostic{Range: lspRange{Start: lspPosition{Line: 0, Character: 23}, End: lspPosition{Line: 0, Character: 63}}, Source: lspSource}
+		messages := runLanguageServer(t,
+			lspNotification("textDocument/didOpen", map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri, "text": awsSecretAccessKeyLine}}),
+			lspRequest(1, "textDocument/codeAction", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": uri},
+				"range":        diagnostic.Range,
+				"context":      map[string]interface{}{"diagnostics": []lspDiagnostic{diagnostic}},
+			}),
+		)
+
+		actions := string(mustMarshal(messages[1].Result))
+		assert.Contains(t, actions, `"newText":"`+awsSecretAccessKeyLine+` # talisman:ignore"`)
+		assert.Contains(t, actions, `"uri":"file://`+filepath.Join(git.GetRoot(), ".talismanrc")+`"`)
+		assert.Contains(t, actions, "filename: settings.yml")
+	})
+}
+
+func TestIgnoringAFindingShouldOnlyEditTheEntryOfTheFileInTalismanRC(t *testing.T) {
+	contents := "# shared by the team\nfileignoreconfig:\n  # generated files\n  - filename: other.txt\n    checksum: 123\n    ignore_detectors: []\nredaction:\n  mode: full # never show secrets\n"
+
+	edit, ok := fileIgnoreTextEdit(contents, "settings.yml", "abc")
+	assert.True(t, ok)
+	assert.Equal(t, "# shared by the team\nfileignoreconfig:\n  # generated files\n  - filename: other.txt\n    checksum: 123\n    ignore_detectors: []\n"+
+		"  - filename: settings.yml\n    checksum: abc\n    ignore_detectors: []\nredaction:\n  mode: full # never show secrets\n", applyTextEdit(contents, edit))
+
+	edit, ok = fileIgnoreTextEdit(contents, "other.txt", "abc")
+	assert.True(t, ok)
+	assert.Equal(t, strings.Replace(contents, "checksum: 123", "checksum: abc", 1), applyTextEdit(contents, edit), "Expected the checksum of the existing entry to be replaced")
+
+	edit, ok = fileIgnoreTextEdit("redaction:\n  mode: full", "settings.yml", "abc")
+	assert.True(t, ok)
+	assert.Equal(t, "redaction:\n  mode: full\nfileignoreconfig:\n- filename: settings.yml\n  checksum: abc\n  ignore_detectors: []\n", applyTextEdit("redaction:\n  mode: full", edit))
+
+	_, ok = fileIgnoreTextEdit("fileignoreconfig: [{filename: other.txt}]\n", "settings.yml", "abc")
+	assert.False(t, ok, "Expected no edit of a list in flow style")
+}
+
+//applyTextEdit applies the edit to the contents, whose lines are expected to be ASCII
+func applyTextEdit(contents string, edit lspTextEdit) string {
+	offset := func(position lspPosition) int {
+		lines := strings.SplitAfter(contents, "\n")
+		result := 0
+		for _, line := range lines[:position.Line] {
+			result += len(line)
+		}
+		return result + position.Character
+	}
+	return contents[:offset(edit.Range.Start)] + edit.NewText + contents[offset(edit.Range.End):]
+}
+
+func TestLanguageServerShouldRespondToUnknownRequestsWithAnError(t *testing.T) {
+	messages := runLanguageServer(t, lspRequest(1, "textDocument/hover", map[string]interface{}{}))
+
+	assert.Equal(t, lspMethodNotFound, messages[0].Error.Code)
+}
+
+func mustMarshal(value interface{}) []byte {
+	encoded, _ := json.Marshal(value)
+	return encoded
+}
+
@@ -0,0 +1,76 @@
+package scan
+
+import (
+	"context"
+	"strings"
+
+	"talisman/detector"
+	"talisman/git_repo"
+)
+
+//Detector is a check that can be plugged into a scan next to, or instead of, the built-in detectors
+type Detector interface {
+	//Name is the category of the findings of the detector, which is also what the ignore_detectors of .talismanrc refer to
+	Name() string
+	//Detect returns the matches found in the input. Inputs that are ignored for the detector in the configuration, or approved by their checksum, are never passed to it.
+	Detect(ctx context.Context, input Input) ([]Match, error)
+}
+
+//Match is a problem a Detector found in an input
+type Match struct {
+	RuleID string
+	//Message describes the problem. A %s verb in it is replaced by the redacted preview of the Secret.
+	Message string
+	//Secret is the matched secret, if any. It is redacted according to the configuration before being reported.
+	Secret string
+	//Warning reports the match without failing the scan
+	Warning bool
+}
+
+//pluggedDetector runs a Detector against a single input as part of a detector.Chain, keeping the error it returns
+type pluggedDetector struct {
+	ctx      context.Context
+	detector Detector
+	input    Input
+	err      error
+}
+
+func (p *pluggedDetector) Test(additions []git_repo.Addition, ignoreConfig detector.TalismanRCIgnore, result *detector.DetectionResults) {
+	name := p.detector.Name()
+	cc := detector.NewChecksumCompare(additions, ignoreConfig)
+	for _, addition := range additions {
+		if ignoreConfig.Deny(addition, name) || cc.IsScanNotRequired(addition) {
+			result.Ignore(addition.Path, name)
+			continue
+		}
+		matches, err := p.detector.Detect(p.ctx, p.input)
+		if err != nil {
+			p.err = err
+			return
+		}
+		for _, match := range matches {
+			report(result, addition, name, match)
+		}
+	}
+}
+
+func report(result *detector.DetectionResults, addition git_repo.Addition, name string, match Match) {
+	ruleID := match.RuleID
+	if ruleID == "" {
+		ruleID = name
+	}
+	message := match.Message
+	if match.Secret != "" && !strings.Contains(message, "%s") {
+		message += ": %s"
+	}
+	switch {
+	case match.Secret == "" && match.Warning:
+		result.Warn(addition.Path, name, message, addition.Commits)
+	case match.Secret == "":
+		result.Fail(addition.Path, name, message, addition.Commits)
+	case match.Warning:
+		result.WarnWithSecret(addition.Path, name, ruleID, message, match.Secret, addition.Commits)
+	default:
+		result.FailWithSecret(addition.Path, name, ruleID, message, match.Secret, addition.Commits)
+	}
+}
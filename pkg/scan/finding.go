@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"talisman/detector"
+)
+
+//Severity states how a finding affects the outcome of a scan
+type Severity string
+
+const (
+	//Failure is the severity of findings that fail a scan
+	Failure Severity = "failure"
+	//Warning is the severity of findings that are reported without failing a scan
+	Warning Severity = "warning"
+	//Ignored is the severity of inputs a detector skipped, as they are ignored in the configuration
+	Ignored Severity = "ignored"
+)
+
+//Secret is the redacted representation of a matched secret, which is safe to log and store
+type Secret struct {
+	Preview string `json:"preview"`
+	Length  int    `json:"length"`
+	Hash    string `json:"hash"`
+}
+
+//Finding is a single problem a detector found in an input, or the note that a detector ignored an input
+type Finding struct {
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	//Detector is the name of the detector, which is also what the ignore_detectors of .talismanrc refer to
+	Detector string  `json:"detector"`
+	RuleID   string  `json:"rule_id,omitempty"`
+	Message  string  `json:"message,omitempty"`
+	Secret   *Secret `json:"secret,omitempty"`
+	//Fingerprint identifies the finding across scans, see detector.Fingerprint
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Commits     []string `json:"commits,omitempty"`
+}
+
+//Results are the findings of a scan, grouped by input in the order of the inputs
+type Results struct {
+	Findings []Finding `json:"findings"`
+}
+
+//HasFailures answers whether any finding fails the scan
+func (r Results) HasFailures() bool {
+	return len(r.WithSeverity(Failure)) > 0
+}
+
+//WithSeverity returns the findings of the given severity
+func (r Results) WithSeverity(severity Severity) []Finding {
+	var result []Finding
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+func (r *Results) add(detectionResults *detector.DetectionResults) {
+	for _, resultsDetails := range detectionResults.Results {
+		path := string(resultsDetails.Filename)
+		for _, details := range resultsDetails.FailureList {
+			r.Findings = append(r.Findings, newFinding(path, Failure, details))
+		}
+		for _, details := range resultsDetails.WarningList {
+			r.Findings = append(r.Findings, newFinding(path, Warning, details))
+		}
+		for _, details := range resultsDetails.IgnoreList {
+			r.Findings = append(r.Findings, newFinding(path, Ignored, details))
+		}
+	}
+}
+
+func newFinding(path string, severity Severity, details detector.Details) Finding {
+	finding := Finding{
+		Path:        path,
+		Severity:    severity,
+		Detector:    details.Category,
+		RuleID:      details.RuleID,
+		Message:     details.Message,
+		Fingerprint: details.Fingerprint,
+		Commits:     details.Commits,
+	}
+	if details.Secret != nil {
+		finding.Secret = &Secret{Preview: details.Secret.Preview, Length: details.Secret.Length, Hash: details.Secret.Hash}
+	}
+	return finding
+}
@@ -0,0 +1,94 @@
+//Package scan is the API to embed talisman in other Go programs.
+//It scans content held in memory, so it neither depends on the working directory, a git repository or stdout,
+//nor logs to the standard logrus logger.
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"talisman/detector"
+	"talisman/git_repo"
+
+	"github.com/Sirupsen/logrus"
+)
+
+//Input is the content of a single file to be scanned
+type Input struct {
+	//Path is the path the content is reported as, and matched against the ignores of the configuration
+	Path string
+	//Content is the whole content of the file
+	Content []byte
+	//Commits optionally lists the commits the content was found in, which are reported along with its findings
+	Commits []string
+}
+
+//Config configures a scan
+type Config struct {
+	//TalismanRC is the content of a .talismanrc file, applied to the inputs as if they were files of its repository
+	TalismanRC []byte
+	//Detectors are run after the built-in detectors
+	Detectors []Detector
+	//DisableBuiltinDetectors only runs the Detectors of the configuration
+	DisableBuiltinDetectors bool
+	//ShowSecrets reports matched secrets in full instead of redacting them
+	ShowSecrets bool
+	//Logger receives the decisions of the detectors. Nothing is logged when it is nil.
+	Logger *logrus.Logger
+}
+
+//Scan runs the detectors of the configuration against the inputs.
+//It returns an error if the configuration cannot be parsed, a detector fails or the context is done before all inputs are scanned.
+func Scan(ctx context.Context, inputs []Input, config Config) (Results, error) {
+	ignores, err := detector.ParseTalismanRCIgnore(config.TalismanRC)
+	if err != nil {
+		return Results{}, fmt.Errorf("unable to parse %s: %v", detector.DefaultRCFileName, err)
+	}
+	redactionPolicy := ignores.Redaction
+	redactionPolicy.ShowSecrets = config.ShowSecrets
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.Out = ioutil.Discard
+	}
+
+	var results Results
+	for _, input := range inputs {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		detectionResults := detector.NewDetectionResults()
+		detectionResults.SetRedactionPolicy(redactionPolicy)
+		detectionResults.SetLogger(logger)
+		chain, pluggedDetectors := newChain(ctx, input, config)
+		chain.Test([]git_repo.Addition{newAddition(input)}, ignores, detectionResults)
+		for _, pluggedDetector := range pluggedDetectors {
+			if pluggedDetector.err != nil {
+				return results, fmt.Errorf("detector %s failed on %s: %v", pluggedDetector.detector.Name(), input.Path, pluggedDetector.err)
+			}
+		}
+		results.add(detectionResults)
+	}
+	return results, nil
+}
+
+func newChain(ctx context.Context, input Input, config Config) (*detector.Chain, []*pluggedDetector) {
+	chain := detector.NewChain()
+	if !config.DisableBuiltinDetectors {
+		chain = detector.DefaultChain()
+	}
+	var pluggedDetectors []*pluggedDetector
+	for _, d := range config.Detectors {
+		pluggedDetector := &pluggedDetector{ctx: ctx, detector: d, input: input}
+		pluggedDetectors = append(pluggedDetectors, pluggedDetector)
+		chain.AddDetector(pluggedDetector)
+	}
+	return chain, pluggedDetectors
+}
+
+func newAddition(input Input) git_repo.Addition {
+	addition := git_repo.NewAddition(input.Path, input.Content)
+	addition.Commits = input.Commits
+	return addition
+}
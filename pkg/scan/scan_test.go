@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"talisman/utility"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const awsSecretAccessKey = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+
+type forbiddenWordDetector struct {
+	word string
+	err  error
+}
+
+func (d forbiddenWordDetector) Name() string {
+	return "forbiddenword"
+}
+
+func (d forbiddenWordDetector) Detect(ctx context.Context, input Input) ([]Match, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if !strings.Contains(string(input.Content), d.word) {
+		return nil, nil
+	}
+	return []Match{{RuleID: "forbidden-word", Message: "Expected file to not to contain %s", Secret: d.word}}, nil
+}
+
+func TestShouldReportRedactedSecretsFoundByBuiltinDetectors(t *testing.T) {
+	inputs := []Input{
+		{Path: "readme.txt", Content: []byte("nothing to see here")},
+		{Path: "config.properties", Content: []byte("secret: " + awsSecretAccessKey), Commits: []string{"abc"}},
+	}
+	results, err := Scan(context.Background(), inputs, Config{})
+
+	assert.Nil(t, err)
+	assert.True(t, results.HasFailures())
+	failures := results.WithSeverity(Failure)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, "config.properties", failures[0].Path)
+	assert.Equal(t, "filecontent", failures[0].Detector)
+	assert.Equal(t, []string{"abc"}, failures[0].Commits)
+	assert.NotEmpty(t, failures[0].RuleID)
+	assert.NotEmpty(t, failures[0].Fingerprint)
+	assert.Equal(t, len(awsSecretAccessKey), failures[0].Secret.Length)
+	assert.NotContains(t, failures[0].Message, awsSecretAccessKey)
+}
+
+func TestShouldReportSecretsInFullWhenConfigured(t *testing.T) {
+	inputs := []Input{{Path: "config.properties", Content: []byte(awsSecretAccessKey)}}
+	results, err := Scan(context.Background(), inputs, Config{ShowSecrets: true})
+
+	assert.Nil(t, err)
+	assert.Equal(t, awsSecretAccessKey, results.WithSeverity(Failure)[0].Secret.Preview)
+}
+
+func TestShouldIgnoreInputsWithChecksumDeclaredInTalismanRC(t *testing.T) {
+	content := []byte(awsSecretAccessKey)
+	talismanRC := fmt.Sprintf("fileignoreconfig:\n- filename: config.properties\n  checksum: %s\n", utility.CollectiveSHA256HashOfContents([]string{"config.properties"}, [][]byte{content}))
+	results, err := Scan(context.Background(), []Input{{Path: "config.properties", Content: content}}, Config{TalismanRC: []byte(talismanRC)})
+
+	assert.Nil(t, err)
+	assert.False(t, results.HasFailures())
+	assert.NotEmpty(t, results.WithSeverity(Ignored))
+}
+
+func TestShouldFailToScanWithUnparsableTalismanRC(t *testing.T) {
+	_, err := Scan(context.Background(), []Input{{Path: "readme.txt"}}, Config{TalismanRC: []byte("fileignoreconfig: [")})
+
+	assert.NotNil(t, err)
+}
+
+func TestShouldRunPluggedDetectors(t *testing.T) {
+	config := Config{Detectors: []Detector{forbiddenWordDetector{word: "hunter2"}}, DisableBuiltinDetectors: true}
+	inputs := []Input{{Path: "private.pem", Content: []byte("password is hunter2")}}
+	results, err := Scan(context.Background(), inputs, config)
+
+	assert.Nil(t, err)
+	assert.True(t, results.HasFailures())
+	assert.Len(t, results.Findings, 1, "Expected only the plugged detector to run")
+	assert.Equal(t, "forbiddenword", results.Findings[0].Detector)
+	assert.Equal(t, "forbidden-word", results.Findings[0].RuleID)
+}
+
+func TestShouldHonourIgnoresOfPluggedDetectors(t *testing.T) {
+	talismanRC := "fileignoreconfig:\n- filename: notes.txt\n  ignore_detectors: [forbiddenword]\n"
+	config := Config{Detectors: []Detector{forbiddenWordDetector{word: "hunter2"}}, TalismanRC: []byte(talismanRC)}
+	results, err := Scan(context.Background(), []Input{{Path: "notes.txt", Content: []byte("password is hunter2")}}, config)
+
+	assert.Nil(t, err)
+	assert.False(t, results.HasFailures())
+}
+
+func TestShouldNotRunPluggedDetectorsOnInputsWithChecksumDeclaredInTalismanRC(t *testing.T) {
+	content := []byte("password is hunter2")
+	talismanRC := fmt.Sprintf("fileignoreconfig:\n- filename: notes.txt\n  checksum: %s\n", utility.CollectiveSHA256HashOfContents([]string{"notes.txt"}, [][]byte{content}))
+	config := Config{Detectors: []Detector{forbiddenWordDetector{word: "hunter2", err: errors.New("should not run")}}, TalismanRC: []byte(talismanRC)}
+	results, err := Scan(context.Background(), []Input{{Path: "notes.txt", Content: content}}, config)
+
+	assert.Nil(t, err, "Expected the plugged detector to not be invoked")
+	assert.False(t, results.HasFailures())
+	assert.NotEmpty(t, results.WithSeverity(Ignored))
+}
+
+func TestShouldReturnErrorsOfPluggedDetectors(t *testing.T) {
+	config := Config{Detectors: []Detector{forbiddenWordDetector{err: errors.New("boom")}}}
+	_, err := Scan(context.Background(), []Input{{Path: "notes.txt"}}, config)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestShouldStopScanningWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Scan(ctx, []Input{{Path: "notes.txt"}}, Config{})
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestShouldNotLogToTheStandardLogger(t *testing.T) {
+	var output bytes.Buffer
+	logrus.SetOutput(&output)
+	logrus.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		logrus.SetLevel(logrus.ErrorLevel)
+	}()
+	_, err := Scan(context.Background(), []Input{{Path: "private.pem", Content: []byte(awsSecretAccessKey)}}, Config{})
+
+	assert.Nil(t, err)
+	assert.Empty(t, output.String())
+}
@@ -6,14 +6,24 @@ import (
 	"talisman/git_repo"
 )
 
-type PreCommitHook struct{}
+type PreCommitHook struct {
+	contextLines int
+	fullFiles    bool
+}
 
 func NewPreCommitHook() *PreCommitHook {
-	return &PreCommitHook{}
+	return &PreCommitHook{contextLines: git_repo.DefaultContextLines}
+}
+
+//ReadingChanges sets the number of unchanged lines read around every change, or makes the hook read the whole staged version of every changed file
+func (p *PreCommitHook) ReadingChanges(contextLines int, fullFiles bool) *PreCommitHook {
+	p.contextLines = contextLines
+	p.fullFiles = fullFiles
+	return p
 }
 
 func (p *PreCommitHook) GetRepoAdditions() []git_repo.Addition {
 	wd, _ := os.Getwd()
-	repo := git_repo.RepoLocatedAt(wd)
+	repo := git_repo.RepoContaining(wd).WithContextLines(p.contextLines).WithFullFiles(p.fullFiles)
 	return repo.GetDiffForStagedFiles()
 }
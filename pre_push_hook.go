@@ -16,10 +16,26 @@ const (
 
 type PrePushHook struct {
 	localRef, localCommit, remoteRef, remoteCommit string
+	recurseSubmodules                              bool
+	contextLines                                   int
+	fullFiles                                      bool
 }
 
 func NewPrePushHook(localRef, localCommit, remoteRef, remoteCommit string) *PrePushHook {
-	return &PrePushHook{localRef, localCommit, remoteRef, remoteCommit}
+	return &PrePushHook{localRef: localRef, localCommit: localCommit, remoteRef: remoteRef, remoteCommit: remoteCommit, contextLines: git_repo.DefaultContextLines}
+}
+
+//ReadingChanges sets the number of unchanged lines read around every change, or makes the hook read the whole pushed version of every changed file
+func (p *PrePushHook) ReadingChanges(contextLines int, fullFiles bool) *PrePushHook {
+	p.contextLines = contextLines
+	p.fullFiles = fullFiles
+	return p
+}
+
+//RecurseSubmodules makes the hook verify the new commits of the submodules whose recorded commit changed as well
+func (p *PrePushHook) RecurseSubmodules(recurse bool) *PrePushHook {
+	p.recurseSubmodules = recurse
+	return p
 }
 
 //If the outgoing ref does not exist on the remote, all commits on the local ref will be checked
@@ -71,6 +87,10 @@ func (p *PrePushHook) getRepoAdditions() []git_repo.Addition {
 
 func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) []git_repo.Addition {
 	wd, _ := os.Getwd()
-	repo := git_repo.RepoLocatedAt(wd)
-	return repo.AdditionsWithinRange(oldCommit, newCommit)
+	repo := git_repo.RepoContaining(wd).WithContextLines(p.contextLines).WithFullFiles(p.fullFiles)
+	additions := repo.AdditionsWithinRange(oldCommit, newCommit)
+	if p.recurseSubmodules {
+		additions = append(additions, repo.SubmoduleAdditionsWithinRange(oldCommit, newCommit)...)
+	}
+	return additions
 }
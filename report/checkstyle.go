@@ -0,0 +1,51 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"talisman/detector"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+//WriteCheckstyle writes the results as a Checkstyle XML report listing every scanned file.
+//Failures are reported as errors and warnings as warnings, on the line the secret was found on when it is known.
+func WriteCheckstyle(w io.Writer, r *detector.DetectionResults) error {
+	report := checkstyleReport{Version: "4.3"}
+	for _, filePath := range r.ScannedFiles() {
+		file := checkstyleFile{Name: string(filePath)}
+		for _, resultDetails := range r.Results {
+			if resultDetails.Filename != filePath {
+				continue
+			}
+			for _, detail := range resultDetails.FailureList {
+				file.Errors = append(file.Errors, checkstyleErrorOf(detail, "error"))
+			}
+			for _, detail := range resultDetails.WarningList {
+				file.Errors = append(file.Errors, checkstyleErrorOf(detail, "warning"))
+			}
+		}
+		report.Files = append(report.Files, file)
+	}
+	return writeXML(w, report)
+}
+
+func checkstyleErrorOf(detail detector.Details, severity string) checkstyleError {
+	return checkstyleError{Line: detail.Line, Severity: severity, Message: detail.Message, Source: "talisman." + ruleOf(detail)}
+}
@@ -0,0 +1,25 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckstyleReportsFindingsWithTheirSeverity(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Scanned("clean.txt")
+	results.Fail("secrets/id_rsa", "filename", "The file name failed checks", []string{})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{})
+
+	var output bytes.Buffer
+	err := WriteCheckstyle(&output, results)
+
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), `<file name="clean.txt"></file>`)
+	assert.Contains(t, output.String(), `<error severity="error" message="The file name failed checks" source="talisman.filename"></error>`)
+	assert.Contains(t, output.String(), `<error severity="warning" message="Expected .talismanrc to not to contain secrets" source="talisman.filecontent"></error>`)
+}
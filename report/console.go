@@ -0,0 +1,130 @@
+package report
+
+import (
+	"io"
+	"strings"
+	"talisman/detector"
+	"unicode/utf8"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	ansiBoldRed = "\x1b[1m\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiReset   = "\x1b[0m"
+)
+
+//tableFrameWidth is the number of columns taken by the borders and padding of a table of three columns
+const tableFrameWidth = 10
+
+//ConsoleReporter writes the warnings and failures as tables, followed by a .talismanrc suggestion for the files with failures.
+//Colours are only written to terminals, unless NO_COLOR is set, and the tables are wrapped to the width of the terminal.
+type ConsoleReporter struct {
+	//Width is the number of columns the tables are wrapped to, instead of the width of the terminal written to
+	Width int
+	//Color writes colours also when not writing to a terminal
+	Color bool
+}
+
+//Report writes the tables of the warnings and failures of the results
+func (c ConsoleReporter) Report(w io.Writer, r *detector.DetectionResults) error {
+	color := c.Color || colorsEnabled(w)
+	width := c.Width
+	if width == 0 {
+		width = widthOf(w)
+	}
+	var out strings.Builder
+	if r.HasWarnings() {
+		out.WriteString("\n" + colored(color, ansiBoldRed, "Talisman Warnings:") + "\n")
+		writeTable(&out, width, "Warnings", r, func(resultDetails detector.ResultsDetails) [][]string {
+			return r.ReportFileWarnings(resultDetails.Filename)
+		})
+		out.WriteString("\n" + colored(color, ansiYellow, "Please review the above file(s) to make sure that no sensitive content is being pushed") + "\n\n")
+	}
+	if r.HasFailures() {
+		out.WriteString("\n" + colored(color, ansiBoldRed, "Talisman Report:") + "\n")
+		writeTable(&out, width, "Errors", r, func(resultDetails detector.ResultsDetails) [][]string {
+			return r.ReportFileFailures(resultDetails.Filename)
+		})
+		out.WriteString("\n" + colored(color, ansiYellow, "If you are absolutely sure that you want to ignore the above files from talisman detectors, consider pasting the following format in .talismanrc file in the project root") + "\n")
+		out.WriteString(r.TalismanRCSuggestion() + "\n\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func writeTable(w io.Writer, width int, heading string, r *detector.DetectionResults, rows func(detector.ResultsDetails) [][]string) {
+	var data [][]string
+	for _, resultDetails := range r.Results {
+		data = append(data, rows(resultDetails)...)
+	}
+	fileWidth, messageWidth := columnWidths(width, data)
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"File", heading, "Fingerprint"})
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+	for _, row := range data {
+		table.Append([]string{wrap(row[0], fileWidth), wrap(row[1], messageWidth), row[2]})
+	}
+	table.Render()
+}
+
+//columnWidths divides the width left by the fingerprints among the files and messages, giving files at most a third of it
+func columnWidths(width int, data [][]string) (int, int) {
+	fingerprintWidth := len("Fingerprint")
+	longestFile := len("File")
+	for _, row := range data {
+		if length := utf8.RuneCountInString(row[2]); length > fingerprintWidth {
+			fingerprintWidth = length
+		}
+		if length := utf8.RuneCountInString(row[0]); length > longestFile {
+			longestFile = length
+		}
+	}
+	available := width - tableFrameWidth - fingerprintWidth
+	fileWidth := longestFile
+	if fileWidth > available/3 {
+		fileWidth = available / 3
+	}
+	messageWidth := available - fileWidth
+	if messageWidth < 20 {
+		messageWidth = 20
+	}
+	return fileWidth, messageWidth
+}
+
+//wrap breaks the text into lines of at most width characters, at spaces where possible
+func wrap(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Split(paragraph, " ") {
+			runes := []rune(word)
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = line[:0]
+			} else if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			for len(line)+len(runes) > width {
+				split := width - len(line)
+				lines = append(lines, string(append(line, runes[:split]...)))
+				line, runes = line[:0], runes[split:]
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func colored(color bool, code string, text string) string {
+	if !color {
+		return text
+	}
+	return code + text + ansiReset
+}
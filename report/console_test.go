@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleReporterWritesFailuresAndSuggestionWithoutColoursWhenNotATerminal(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{})
+
+	var output bytes.Buffer
+	err := ConsoleReporter{}.Report(&output, results)
+
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), "Talisman Warnings:")
+	assert.Contains(t, output.String(), "Talisman Report:")
+	assert.Regexp(t, `\| some_file.pem \| Bomb +\|`, output.String())
+	assert.Contains(t, output.String(), "- filename: some_file.pem")
+	assert.NotContains(t, output.String(), "\x1b[", "Expected no colours when not writing to a terminal")
+}
+
+func TestConsoleReporterWritesNothingForSuccessfulResults(t *testing.T) {
+	var output bytes.Buffer
+	ConsoleReporter{}.Report(&output, detector.NewDetectionResults())
+
+	assert.Empty(t, output.String())
+}
+
+func TestConsoleReporterColoursWhenAskedTo(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Bomb", []string{})
+
+	var output bytes.Buffer
+	ConsoleReporter{Color: true}.Report(&output, results)
+
+	assert.Contains(t, output.String(), "\x1b[1m\x1b[31mTalisman Report:\x1b[0m")
+}
+
+func TestConsoleReporterWrapsTablesToTheWidth(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("some_file.pem", "filecontent", "Expected file to not to contain base64 encoded texts such as: "+strings.Repeat("c3VwZXJzZWNyZXQ=", 20), []string{})
+
+	var output bytes.Buffer
+	ConsoleReporter{Width: 80}.Report(&output, results)
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		if strings.HasPrefix(line, "|") || strings.HasPrefix(line, "+") {
+			assert.True(t, len(line) <= 80, "Expected table lines of at most 80 characters, got %q", line)
+		}
+	}
+}
+
+func TestColoursAreDisabledByNoColor(t *testing.T) {
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+
+	assert.False(t, colorsEnabled(os.Stdout))
+}
+
+func TestWrapBreaksAtSpacesAndSplitsLongWords(t *testing.T) {
+	assert.Equal(t, "some\nwords\nabcde\nfghij\nk", wrap("some words abcdefghijk", 5))
+	assert.Equal(t, "short text", wrap("short text", 0))
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"talisman/detector"
+	"time"
+)
+
+//TableFormat is the output format of the tables printed by default
+const TableFormat = "table"
+
+//TextFormat is the output format printing a line per finding, without tables or colours
+const TextFormat = "text"
+
+//Reporter writes the results of a run in a particular output format
+type Reporter interface {
+	Report(w io.Writer, r *detector.DetectionResults) error
+}
+
+//ReporterFunc lets an ordinary function writing the results be used as a Reporter
+type ReporterFunc func(w io.Writer, r *detector.DetectionResults) error
+
+//Report calls f(w, r)
+func (f ReporterFunc) Report(w io.Writer, r *detector.DetectionResults) error {
+	return f(w, r)
+}
+
+//Run describes the talisman run the results were collected in, for the formats reporting on the scan itself
+type Run struct {
+	Version string
+	Start   time.Time
+	End     time.Time
+}
+
+type format struct {
+	reporter func(run Run) Reporter
+	//machineReadable formats are meant to be processed by tools, so nothing else may be written along with them
+	machineReadable bool
+}
+
+var formats = map[string]format{
+	TableFormat:  {reporter: func(Run) Reporter { return ConsoleReporter{} }},
+	TextFormat:   {reporter: func(Run) Reporter { return TextReporter{} }},
+	"json":       {reporter: func(Run) Reporter { return ReporterFunc(WriteJSON) }, machineReadable: true},
+	"junit":      {reporter: func(Run) Reporter { return ReporterFunc(WriteJUnit) }, machineReadable: true},
+	"checkstyle": {reporter: func(Run) Reporter { return ReporterFunc(WriteCheckstyle) }, machineReadable: true},
+	"markdown":   {reporter: func(Run) Reporter { return ReporterFunc(WriteMarkdown) }, machineReadable: true},
+	"gitlab": {
+		reporter: func(run Run) Reporter {
+			return ReporterFunc(func(w io.Writer, r *detector.DetectionResults) error { return WriteGitLab(w, r, run) })
+		},
+		machineReadable: true,
+	},
+}
+
+//ReporterFor returns the reporter of the output format, or an error naming the supported formats if there is none.
+//The empty format is the default table.
+func ReporterFor(format string, run Run) (Reporter, error) {
+	if format == "" {
+		format = TableFormat
+	}
+	f, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, expected one of %v", format, Formats())
+	}
+	return f.reporter(run), nil
+}
+
+//IsMachineReadable answers whether the output format is meant to be processed by tools rather than read by people
+func IsMachineReadable(format string) bool {
+	return formats[format].machineReadable
+}
+
+//Formats returns the names of the supported output formats, the default table first
+func Formats() []string {
+	result := []string{TableFormat}
+	for name := range formats {
+		if name != TableFormat {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result[1:])
+	return result
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"talisman/detector"
+)
+
+//GitLabSchemaVersion is the version of the GitLab secret detection report schema WriteGitLab writes
+const GitLabSchemaVersion = "15.0.6"
+
+//gitLabUnknownCommit is the commit GitLab expects for findings not tied to a commit, e.g. in files that are not committed yet
+const gitLabUnknownCommit = "0000000"
+
+//gitLabShaPattern matches the abbreviated or full sha of a commit
+var gitLabShaPattern = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+
+const gitLabTimeFormat = "2006-01-02T15:04:05"
+
+type gitLabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []gitLabVulnerability `json:"vulnerabilities"`
+	Scan            gitLabScan            `json:"scan"`
+}
+
+type gitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Category    string             `json:"category"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Severity    string             `json:"severity"`
+	Scanner     gitLabScanner      `json:"scanner"`
+	Location    gitLabLocation     `json:"location"`
+	Identifiers []gitLabIdentifier `json:"identifiers"`
+}
+
+type gitLabScanner struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Version string        `json:"version,omitempty"`
+	URL     string        `json:"url,omitempty"`
+	Vendor  *gitLabVendor `json:"vendor,omitempty"`
+}
+
+type gitLabVendor struct {
+	Name string `json:"name"`
+}
+
+type gitLabLocation struct {
+	File      string       `json:"file"`
+	Commit    gitLabCommit `json:"commit"`
+	StartLine int          `json:"start_line,omitempty"`
+	EndLine   int          `json:"end_line,omitempty"`
+}
+
+type gitLabCommit struct {
+	Sha string `json:"sha"`
+}
+
+type gitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type gitLabScan struct {
+	Analyzer  gitLabScanner   `json:"analyzer"`
+	Scanner   gitLabScanner   `json:"scanner"`
+	Type      string          `json:"type"`
+	StartTime string          `json:"start_time"`
+	EndTime   string          `json:"end_time"`
+	Status    string          `json:"status"`
+	Messages  []gitLabMessage `json:"messages,omitempty"`
+}
+
+type gitLabMessage struct {
+	Level string `json:"level"`
+	Value string `json:"value"`
+}
+
+//WriteGitLab writes the results as a GitLab secret detection report, as ingested by the GitLab security dashboard from gl-secret-detection-report.json.
+//Every failure and warning becomes a vulnerability, located in the first commit it was found in. Files that could not be verified are reported as scan messages instead.
+func WriteGitLab(w io.Writer, r *detector.DetectionResults, run Run) error {
+	scanner := gitLabScanner{
+		ID:      "talisman",
+		Name:    "Talisman",
+		Version: run.Version,
+		URL:     "https://github.com/thoughtworks/talisman",
+		Vendor:  &gitLabVendor{Name: "ThoughtWorks"},
+	}
+	report := gitLabReport{
+		Version:         GitLabSchemaVersion,
+		Vulnerabilities: []gitLabVulnerability{},
+		Scan: gitLabScan{
+			Analyzer:  scanner,
+			Scanner:   scanner,
+			Type:      "secret_detection",
+			StartTime: run.Start.UTC().Format(gitLabTimeFormat),
+			EndTime:   run.End.UTC().Format(gitLabTimeFormat),
+			Status:    "success",
+		},
+	}
+	for _, resultDetails := range r.Results {
+		for _, detail := range resultDetails.FailureList {
+			if detail.Category == detector.ErrorCategory {
+				report.Scan.Messages = append(report.Scan.Messages, gitLabMessage{Level: "warn", Value: detail.Message})
+				continue
+			}
+			report.Vulnerabilities = append(report.Vulnerabilities, gitLabVulnerabilityOf(string(resultDetails.Filename), detail, "Critical", scanner))
+		}
+		for _, detail := range resultDetails.WarningList {
+			report.Vulnerabilities = append(report.Vulnerabilities, gitLabVulnerabilityOf(string(resultDetails.Filename), detail, "Medium", scanner))
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func gitLabVulnerabilityOf(file string, detail detector.Details, severity string, scanner gitLabScanner) gitLabVulnerability {
+	commit := gitLabCommitOf(detail.Commits)
+	rule := ruleOf(detail)
+	return gitLabVulnerability{
+		ID:          gitLabID(detail.Fingerprint, commit),
+		Category:    "secret_detection",
+		Name:        fmt.Sprintf("Talisman %s finding", rule),
+		Description: detail.Message,
+		Severity:    severity,
+		Scanner:     gitLabScanner{ID: scanner.ID, Name: scanner.Name},
+		Location:    gitLabLocation{File: file, Commit: gitLabCommit{Sha: commit}, StartLine: detail.Line, EndLine: detail.Line},
+		Identifiers: []gitLabIdentifier{{Type: "talisman_rule_id", Name: "Talisman rule " + rule, Value: rule}},
+	}
+}
+
+//gitLabCommitOf returns the sha of the first of the commits, without the origin the scanner labels commits that are not reachable from the scanned refs with,
+//e.g. "<sha> (stash@{0})". Blobs not found in any commit are reported in gitLabUnknownCommit.
+func gitLabCommitOf(commits []string) string {
+	for _, commit := range commits {
+		sha := strings.Fields(commit)
+		if len(sha) > 0 && gitLabShaPattern.MatchString(sha[0]) {
+			return sha[0]
+		}
+	}
+	return gitLabUnknownCommit
+}
+
+//gitLabID derives a stable UUID-formatted identifier from the finding, so that GitLab can track it across pipelines
+func gitLabID(parts ...string) string {
+	sum := sha256.New()
+	for _, part := range parts {
+		sum.Write([]byte(part))
+		sum.Write([]byte{0})
+	}
+	id := hex.EncodeToString(sum.Sum(nil))
+	return fmt.Sprintf("%s-%s-%s-%s-%s", id[0:8], id[8:12], id[12:16], id[16:20], id[20:32])
+}
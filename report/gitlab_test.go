@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitLabReportsFindingsAsSecretDetectionVulnerabilities(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.FailWithSecret("config.yml", "filecontent", detector.Base64RuleID, "Expected file to not to contain base64 encoded texts such as: %s", "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", []string{"c0ffee", "decade"})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{})
+	results.Fail("unreadable.txt", detector.ErrorCategory, "Unable to read unreadable.txt", []string{})
+	results.Ignore("docs/README.md", "filecontent")
+	start := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var output bytes.Buffer
+	err := WriteGitLab(&output, results, Run{Version: "v1.2.3", Start: start, End: start.Add(time.Minute)})
+
+	assert.NoError(t, err)
+	var report gitLabReport
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &report))
+	assert.Equal(t, GitLabSchemaVersion, report.Version)
+	assert.Equal(t, "secret_detection", report.Scan.Type)
+	assert.Equal(t, "v1.2.3", report.Scan.Scanner.Version)
+	assert.Equal(t, "2019-01-02T03:04:05", report.Scan.StartTime)
+	assert.Equal(t, "2019-01-02T03:05:05", report.Scan.EndTime)
+	assert.Equal(t, []gitLabMessage{{Level: "warn", Value: "Unable to read unreadable.txt"}}, report.Scan.Messages)
+	assert.Len(t, report.Vulnerabilities, 2, "Expected a vulnerability for every failure and warning")
+	failure := report.Vulnerabilities[0]
+	assert.Equal(t, "Critical", failure.Severity)
+	assert.Equal(t, gitLabLocation{File: "config.yml", Commit: gitLabCommit{Sha: "c0ffee"}}, failure.Location)
+	assert.Equal(t, detector.Base64RuleID, failure.Identifiers[0].Value)
+	assert.NotContains(t, output.String(), "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", "Secrets should only appear redacted")
+	warning := report.Vulnerabilities[1]
+	assert.Equal(t, "Medium", warning.Severity)
+	assert.Equal(t, gitLabUnknownCommit, warning.Location.Commit.Sha, "Expected findings without commits to be reported in an unknown commit")
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$", warning.ID)
+}
+
+func TestGitLabReportsFindingsOutsideTheScannedRefsInTheirCommitOrAnUnknownOne(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("stashed.pem", "filename", "The file name stashed.pem failed checks", []string{"c0ffee1 (stash@{0})"})
+	results.Fail("dropped.pem", "filename", "The file name dropped.pem failed checks", []string{"decade1 (reflog)"})
+	results.Fail("0123abcd", "filename", "The file name 0123abcd failed checks", []string{"unreachable blob"})
+
+	var output bytes.Buffer
+	err := WriteGitLab(&output, results, Run{Version: "v1.2.3"})
+
+	assert.NoError(t, err)
+	var report gitLabReport
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &report))
+	var shas []string
+	for _, vulnerability := range report.Vulnerabilities {
+		shas = append(shas, vulnerability.Location.Commit.Sha)
+	}
+	assert.Equal(t, []string{"c0ffee1", "decade1", gitLabUnknownCommit}, shas)
+}
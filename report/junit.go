@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"talisman/detector"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	Skipped   *junitSkipped  `xml:"skipped"`
+	SystemOut *junitOutput   `xml:"system-out"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+//WriteJUnit writes the results as a JUnit XML test suite with a test case for every scanned file.
+//Every failure of a file fails its test case, files that could not be verified error, and files that were ignored only are skipped.
+func WriteJUnit(w io.Writer, r *detector.DetectionResults) error {
+	suite := junitTestSuite{Name: "talisman"}
+	for _, filePath := range r.ScannedFiles() {
+		testCase := junitTestCase{Name: string(filePath), ClassName: "talisman", File: string(filePath)}
+		for _, resultDetails := range r.Results {
+			if resultDetails.Filename != filePath {
+				continue
+			}
+			for _, detail := range resultDetails.FailureList {
+				problem := junitProblem{Message: detail.Message, Type: ruleOf(detail), Text: describe(detail)}
+				if detail.Category == detector.ErrorCategory {
+					testCase.Errors = append(testCase.Errors, problem)
+				} else {
+					testCase.Failures = append(testCase.Failures, problem)
+				}
+			}
+			var warnings []string
+			for _, detail := range resultDetails.WarningList {
+				warnings = append(warnings, "Warning: "+detail.Message+"\n"+describe(detail))
+			}
+			if len(warnings) > 0 {
+				testCase.SystemOut = &junitOutput{Text: strings.Join(warnings, "\n\n")}
+			}
+			if len(resultDetails.IgnoreList) > 0 && len(resultDetails.FailureList) == 0 {
+				testCase.Skipped = &junitSkipped{Message: "Ignored by .talismanrc for " + strings.Join(categoriesOf(resultDetails.IgnoreList), ", ")}
+			}
+		}
+		suite.Tests++
+		if len(testCase.Errors) > 0 {
+			suite.Errors++
+		} else if len(testCase.Failures) > 0 {
+			suite.Failures++
+		} else if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	return writeXML(w, junitTestSuites{Suites: []junitTestSuite{suite}})
+}
+
+//ruleOf returns the rule a finding was reported by, or its detector when the detector has a single rule
+func ruleOf(detail detector.Details) string {
+	if detail.RuleID == "" {
+		return detail.Category
+	}
+	return detail.RuleID
+}
+
+//describe returns the details of a finding beyond its message, one per line
+func describe(detail detector.Details) string {
+	lines := []string{"Detector: " + detail.Category, "Rule: " + ruleOf(detail)}
+	if detail.Line != 0 {
+		lines = append(lines, fmt.Sprintf("Line: %d", detail.Line))
+	}
+	if detail.Fingerprint != "" {
+		lines = append(lines, "Fingerprint: "+detail.Fingerprint)
+	}
+	if len(detail.Commits) > 0 {
+		lines = append(lines, "Commits: "+strings.Join(detail.Commits, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func categoriesOf(detailsList []detector.Details) []string {
+	var categories []string
+	for _, detail := range detailsList {
+		categories = append(categories, detail.Category)
+	}
+	return categories
+}
+
+func writeXML(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
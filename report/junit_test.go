@@ -0,0 +1,43 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitHasATestCaseForEveryScannedFile(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Scanned("clean.txt")
+	results.Fail("secrets/id_rsa", "filename", "The file name failed checks", []string{"c0ffee"})
+	results.Fail("secrets/id_rsa", "filesize", "The file is too large", []string{"c0ffee"})
+	results.Fail("unreadable.txt", detector.ErrorCategory, "Unable to read unreadable.txt", []string{})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{})
+	results.Ignore("docs/README.md", "filecontent")
+
+	var output bytes.Buffer
+	err := WriteJUnit(&output, results)
+
+	assert.NoError(t, err)
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &suites), "Expected well formed JUnit XML")
+	suite := suites.Suites[0]
+	assert.Equal(t, 5, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Errors)
+	assert.Equal(t, 1, suite.Skipped)
+	cases := make(map[string]junitTestCase)
+	for _, testCase := range suite.Cases {
+		cases[testCase.Name] = testCase
+	}
+	assert.Empty(t, cases["clean.txt"].Failures)
+	assert.Len(t, cases["secrets/id_rsa"].Failures, 2, "Expected a failure for every finding")
+	assert.Contains(t, cases["secrets/id_rsa"].Failures[0].Text, "Commits: c0ffee")
+	assert.Len(t, cases["unreadable.txt"].Errors, 1)
+	assert.Contains(t, cases[".talismanrc"].SystemOut.Text, "Expected .talismanrc to not to contain secrets")
+	assert.NotNil(t, cases["docs/README.md"].Skipped)
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"talisman/detector"
+)
+
+//MarkdownMaxLength is the largest markdown WriteMarkdown writes, which leaves room within the 65536 characters of a GitHub comment
+const MarkdownMaxLength = 60000
+
+var markdownCellEscaper = strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;", "\r", "", "\n", " ")
+
+//WriteMarkdown writes the results as markdown meant for pull request comments: a summary, a collapsible table of findings with redacted secrets and a .talismanrc suggestion.
+//Findings that do not fit within MarkdownMaxLength are left out, and a suggestion too large to fit is replaced by a note.
+func WriteMarkdown(w io.Writer, r *detector.DetectionResults) error {
+	_, err := io.WriteString(w, markdown(r, MarkdownMaxLength))
+	return err
+}
+
+func markdown(r *detector.DetectionResults, maxLength int) string {
+	summary := markdownSummary(r)
+	suggestion := markdownSuggestion(r)
+	if len(suggestion) > (maxLength-len(summary))/2 {
+		suggestion = "\n_The `.talismanrc` suggestion is too large for this comment. Run talisman locally to get it._\n"
+	}
+	findings := markdownFindings(r, maxLength-len(summary)-len(suggestion))
+	return summary + findings + suggestion
+}
+
+func markdownSummary(r *detector.DetectionResults) string {
+	types := r.Summary.Types
+	var summary strings.Builder
+	summary.WriteString("## Talisman scan results\n\n")
+	if r.HasFailures() {
+		fmt.Fprintf(&summary, ":x: **%s** found", plural(failureCount(types), "failure"))
+	} else {
+		summary.WriteString(":white_check_mark: **No failures** found")
+	}
+	fmt.Fprintf(&summary, ", %s, %s.\n\n", plural(types.Warnings, "warning"), plural(types.Ignores, "ignore"))
+	summary.WriteString("| Detector | Failures |\n|---|---:|\n")
+	fmt.Fprintf(&summary, "| File content | %d |\n", types.Filecontent)
+	fmt.Fprintf(&summary, "| File name | %d |\n", types.Filename)
+	fmt.Fprintf(&summary, "| File size | %d |\n", types.Filesize)
+	fmt.Fprintf(&summary, "| Other detectors | %d |\n", types.Others)
+	fmt.Fprintf(&summary, "| Errors | %d |\n", types.Errors)
+	return summary.String()
+}
+
+func markdownFindings(r *detector.DetectionResults, maxLength int) string {
+	var rows []string
+	for _, resultDetails := range r.Results {
+		for _, detail := range resultDetails.FailureList {
+			rows = append(rows, markdownRow(":x:", resultDetails, detail))
+		}
+		for _, detail := range resultDetails.WarningList {
+			rows = append(rows, markdownRow(":warning:", resultDetails, detail))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	header := fmt.Sprintf("\n<details>\n<summary>%s</summary>\n\n| | File | Line | Rule | Finding | Fingerprint |\n|---|---|---:|---|---|---|\n", plural(len(rows), "finding"))
+	footer := "\n</details>\n"
+	budget := maxLength - len(header) - len(footer) - len(notShown(len(rows)))
+	var table strings.Builder
+	shown := 0
+	for _, row := range rows {
+		if table.Len()+len(row) > budget {
+			break
+		}
+		table.WriteString(row)
+		shown++
+	}
+	if shown < len(rows) {
+		table.WriteString(notShown(len(rows) - shown))
+	}
+	return header + table.String() + footer
+}
+
+func notShown(count int) string {
+	return fmt.Sprintf("\n_...and %s not shown. See the full report for all of them._\n", plural(count, "more finding"))
+}
+
+func markdownRow(severity string, resultDetails detector.ResultsDetails, detail detector.Details) string {
+	file := string(resultDetails.Filename)
+	if resultDetails.OldFilename != "" {
+		file = fmt.Sprintf("%s (from %s)", file, resultDetails.OldFilename)
+	}
+	line := ""
+	if detail.Line != 0 {
+		line = fmt.Sprint(detail.Line)
+	}
+	return fmt.Sprintf("| %s | %s | %s | `%s` | %s | `%s` |\n", severity, markdownCell(file), line, markdownCell(ruleOf(detail)), markdownCell(detail.Message), detail.Fingerprint)
+}
+
+func markdownSuggestion(r *detector.DetectionResults) string {
+	suggestion := r.TalismanRCSuggestion()
+	if suggestion == "" {
+		return ""
+	}
+	return "\n<details>\n<summary>.talismanrc suggestion</summary>\n\n" +
+		"If you are absolutely sure that the files with failures hold no secrets, paste the following into the `.talismanrc` file in the project root:\n\n" +
+		"```yaml\n" + suggestion + "```\n\n</details>\n"
+}
+
+func markdownCell(text string) string {
+	return markdownCellEscaper.Replace(strings.Replace(text, "`", "'", -1))
+}
+
+func failureCount(types detector.FailureTypes) int {
+	return types.Filecontent + types.Filename + types.Filesize + types.Others + types.Errors
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"talisman/detector"
+	"talisman/git_repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownSummarisesFindingsWithRedactedSecrets(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.FailWithSecret("config.yml", "filecontent", detector.Base64RuleID, "Expected file to not to contain base64 encoded texts such as: %s", "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", []string{})
+	results.Warn("notes|todo.txt", "filecontent", "Expected <notes> to be reviewed", []string{})
+
+	var output bytes.Buffer
+	err := WriteMarkdown(&output, results)
+
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), ":x: **1 failure** found, 1 warning, 0 ignores.")
+	assert.Contains(t, output.String(), "| File content | 1 |")
+	assert.Contains(t, output.String(), "<summary>2 findings</summary>")
+	assert.Contains(t, output.String(), "| :warning: | notes\\|todo.txt |  | `filecontent` | Expected &lt;notes&gt; to be reviewed |")
+	assert.NotContains(t, output.String(), "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", "Secrets should only appear redacted")
+	assert.Contains(t, output.String(), "```yaml\nfileignoreconfig:\n- filename: config.yml\n")
+	assert.NotContains(t, output.String(), "filename: notes|todo.txt", "Expected suggestions for files with failures only")
+}
+
+func TestMarkdownWithoutFailuresHasNoSuggestion(t *testing.T) {
+	var output bytes.Buffer
+	WriteMarkdown(&output, detector.NewDetectionResults())
+
+	assert.Contains(t, output.String(), ":white_check_mark: **No failures** found")
+	assert.NotContains(t, output.String(), "<details>")
+}
+
+func TestMarkdownTruncatesFindingsToTheMaximumLength(t *testing.T) {
+	results := detector.NewDetectionResults()
+	for i := 0; i < 100; i++ {
+		results.Fail(git_repo.FilePath(fmt.Sprintf("secrets/some_file_%03d.pem", i)), "filename", "The file name failed checks", []string{})
+	}
+
+	output := markdown(results, 5000)
+
+	assert.True(t, len(output) <= 5000, "Expected at most 5000 characters, got %d", len(output))
+	assert.Contains(t, output, "<summary>100 findings</summary>")
+	assert.Regexp(t, `_\.\.\.and \d+ more findings not shown`, output)
+	assert.Contains(t, output, "suggestion is too large for this comment")
+	assert.True(t, strings.HasSuffix(output, "</details>\n\n_The `.talismanrc` suggestion is too large for this comment. Run talisman locally to get it._\n"))
+}
@@ -3,15 +3,48 @@ package report
 import (
 	"encoding/json"
 	"html/template"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"talisman/detector"
+	"talisman/git_repo"
 )
 
 const reportsFolder = "talisman_reports"
 const htmlFileName string = "report.html"
 const jsonFileName string = "report.json"
+
+//finding is a single failure, warning or ignore of a file as rendered in a row of the html report
+type finding struct {
+	Filename    git_repo.FilePath
+	OldFilename git_repo.FilePath
+	detector.Details
+}
+
+var reportFunctions = template.FuncMap{
+	"finding": func(r detector.ResultsDetails, d detector.Details) finding {
+		return finding{Filename: r.Filename, OldFilename: r.OldFilename, Details: d}
+	},
+	"failures": func(results []detector.ResultsDetails) int {
+		return count(results, func(r detector.ResultsDetails) []detector.Details { return r.FailureList })
+	},
+	"warnings": func(results []detector.ResultsDetails) int {
+		return count(results, func(r detector.ResultsDetails) []detector.Details { return r.WarningList })
+	},
+	"ignores": func(results []detector.ResultsDetails) int {
+		return count(results, func(r detector.ResultsDetails) []detector.Details { return r.IgnoreList })
+	},
+}
+
+func count(results []detector.ResultsDetails, list func(detector.ResultsDetails) []detector.Details) int {
+	total := 0
+	for _, result := range results {
+		total += len(list(result))
+	}
+	return total
+}
+
 // GenerateReport generates a talisman scan report in html format
 func GenerateReport(r *detector.DetectionResults, directory string) string {
 
@@ -24,15 +57,13 @@ func GenerateReport(r *detector.DetectionResults, directory string) string {
 	jsonFilePath = filepath.Join(directory, reportsFolder, jsonFileName)
 	os.MkdirAll(path, 0755)
 
-
-	reportHTML := getReportHTML()
-	reportTemplate := template.New("report")
-	reportTemplate, _ = reportTemplate.Parse(reportHTML)
 	htmlFile, err := os.Create(htmlFilePath)
 	if err != nil {
 		log.Fatal("Cannot create report.html file", err)
 	}
-	reportTemplate.ExecuteTemplate(htmlFile, "report", r)
+	if err := writeHTMLReport(htmlFile, r); err != nil {
+		log.Fatal("Unable to render report.html ", err)
+	}
 	htmlFile.Close()
 
 	jsonFile, err := os.Create(jsonFilePath)
@@ -40,70 +71,131 @@ func GenerateReport(r *detector.DetectionResults, directory string) string {
 		log.Fatal("Cannot create report.json file", err)
 
 	}
-	//jsonResultSchema := detector.GetJsonSchema(r)
-	jsonString, err := json.Marshal(r)
-	if err != nil {
-		log.Fatal("Unable to marshal JSON")
+	if err := WriteJSON(jsonFile, r); err != nil {
+		log.Fatal("Unable to write report.json ", err)
 	}
-	jsonFile.Write(jsonString)
 	jsonFile.Close()
 	return path
 }
 
+//WriteJSON writes the results in the schema of report.json
+func WriteJSON(w io.Writer, r *detector.DetectionResults) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+//writeHTMLReport renders the results as a self-contained html page, without any external assets
+func writeHTMLReport(w io.Writer, r *detector.DetectionResults) error {
+	reportTemplate, err := template.New("report").Funcs(reportFunctions).Parse(getReportHTML())
+	if err != nil {
+		return err
+	}
+	return reportTemplate.ExecuteTemplate(w, "report", r)
+}
+
 func getReportHTML() string {
 	return `
 	<html>
 		<head>
+			<meta charset="utf-8">
 			<style>
 				body {
 					background-image: radial-gradient(at center center, rgb(69, 72, 77) 0%, rgb(17, 17, 17) 100%);
+					background-attachment: fixed;
 					font-family: "Helvetica Neue",Helvetica,sans-serif;
-				}
-				svg.banner {
-					width: 5%;
-					height: auto;
+					color: lightgrey;
 				}
 				table {
 					border-collapse: collapse;
 				}
-				.commits_table, .details {
-					width: 100%;
-				}
-				.report-table {
+				.scan-report {
 					width: 80%;
 					margin: auto;
+				}
+				.summary {
+					display: flex;
+					flex-wrap: wrap;
+					justify-content: center;
+					margin-bottom: 30px;
+				}
+				.summary-count {
+					min-width: 110px;
+					margin: 6px;
+					padding: 12px;
+					border-radius: 4px;
 					background-color: #DCDCDC;
+					color: #111;
+					text-align: center;
+				}
+				.summary-count .value {
+					display: block;
+					font-size: 28px;
+				}
+				.summary-count.failure .value {
+					color: #b22222;
+				}
+				.summary-count.warning .value {
+					color: #b8860b;
+				}
+				.summary-count.ignore .value {
+					color: dimgrey;
+				}
+				.filters {
+					margin-bottom: 20px;
+				}
+				.filters input[type=search], .filters select {
+					padding: 6px;
+					margin-right: 12px;
+				}
+				.filters input[type=search] {
+					width: 30%;
+				}
+				.filters label {
+					margin-right: 12px;
+				}
+				h2 {
+					font-weight: 300;
+				}
+				.report-table {
+					width: 100%;
+					background-color: #DCDCDC;
+					color: #111;
+					margin-bottom: 30px;
 				}
 				td, th {
-					border: 1px solid #dddddd;
+					border: 1px solid grey;
 					padding: 8px;
-					text-align: center;
-				}
-				.report-table > tbody > tr > td:first-child {
+					text-align: left;
 					vertical-align: top;
 				}
 				.report-table th {
 					background-color: dimgrey;
 					color: lightgrey;
+					cursor: pointer;
+					user-select: none;
 				}
-				.report-table > tbody > tr > td {
-					border: 1px solid grey;
+				.report-table th[aria-sort=ascending]::after {
+					content: " \25B2";
 				}
-				.failure-message {
-					word-break: break-word;
+				.report-table th[aria-sort=descending]::after {
+					content: " \25BC";
 				}
-				#message-heading {
-					margin-left: 15%;
+				.message {
+					word-break: break-word;
 				}
-				#commit-heading {
-					margin-left: 35%;
+				.renamed, .fingerprint {
+					display: block;
+					font-size: 12px;
+					color: dimgrey;
 				}
-				.details > tbody > tr > td {
-					border: 1px solid #A9A9A9;
-					width: 60%;
+				.commits {
+					margin: 0;
+					padding: 0;
+					list-style: none;
+					font-family: monospace;
 				}
-				#file-path {
-					width: 25%;
+				.empty {
+					color: dimgrey;
+					text-align: center;
 				}
 				#heading {
 					font-size: 40px;
@@ -118,7 +210,6 @@ func getReportHTML() string {
 				.site-footer {
 					border-top: 1px solid #424242;
 					padding: 20px 0 25px 0;
-					position: absolute;
 					text-align: center;
 					width: 100%;
 					margin-top: 40px;
@@ -136,24 +227,6 @@ func getReportHTML() string {
 				a:hover {
 					color: #111;
 				}
-				.scan-report {
-					min-height: calc(100vh - 400px);
-				}
-				.details > tbody > tr:first-child > td:last-child {
-					border-top: 0px;
-				}
-				.details > tbody > tr:last-child > td:first-child {
-					border-bottom: 0px;
-				}
-				.details > tbody > tr > td:last-child {
-					border-bottom: 0px;
-					border-right: 0px;
-				}
-				.details > tbody > tr > td:first-child {
-					border-top: 0px;
-					border-left: 0px;
-				}
-
 			</style>
 			<title>Talisman Report</title>
 		</head>
@@ -295,38 +368,52 @@ func getReportHTML() string {
 
 			<h1 id="heading">Talisman Scan Report</h1>
 			<div class="scan-report">
-				<table class="report-table">
-					<tr>
-						<th id="file-path">File Path</th>
-						<th>
-							<span id="message-heading">Message</span>
-							<span id="commit-heading">Found in Commit (Git SHAs)</span>
-						</th>
-					</tr>
-					{{range $filePath, $FailureData := .Failures}}
-						<tr>
-							<td>{{$filePath}}</td>
-							<td>
-								<table class="details">
-									{{range $failureMessage, $commits := $FailureData.FailuresInCommits}}
-										<tr>
-											<td class="failure-message">
-												{{$failureMessage}}
-											</td>
-											<td>
-												<table>
-												{{range $commit := $commits}}
-													<tr><td>{{$commit}}</td></tr>
-												{{end}}
-												</table>
-											</td>
-										</tr>
-									{{end}}
-								</table>
-							</td>
-						</tr>
-					{{end}}
-				</table>
+				<div class="summary">
+					<div class="summary-count failure"><span class="value">{{.Summary.Types.Filecontent}}</span>File content</div>
+					<div class="summary-count failure"><span class="value">{{.Summary.Types.Filename}}</span>File name</div>
+					<div class="summary-count failure"><span class="value">{{.Summary.Types.Filesize}}</span>File size</div>
+					<div class="summary-count failure"><span class="value">{{.Summary.Types.Others}}</span>Other detectors</div>
+					<div class="summary-count failure"><span class="value">{{.Summary.Types.Errors}}</span>Errors</div>
+					<div class="summary-count warning"><span class="value">{{.Summary.Types.Warnings}}</span>Warnings</div>
+					<div class="summary-count ignore"><span class="value">{{.Summary.Types.Ignores}}</span>Ignores</div>
+				</div>
+				<div class="filters">
+					<input type="search" id="filter-text" placeholder="Filter by file, message, rule or commit">
+					<select id="filter-category"><option value="">All detectors</option></select>
+					<label><input type="checkbox" class="filter-section" value="failures" checked> Failures</label>
+					<label><input type="checkbox" class="filter-section" value="warnings" checked> Warnings</label>
+					<label><input type="checkbox" class="filter-section" value="ignores" checked> Ignores</label>
+				</div>
+				<section id="failures">
+					<h2>Failures ({{failures .Results}})</h2>
+					<table class="report-table">
+						{{template "header"}}
+						<tbody>
+						{{range $result := .Results}}{{range $detail := $result.FailureList}}{{template "row" (finding $result $detail)}}{{end}}{{end}}
+						{{if eq (failures .Results) 0}}<tr class="empty"><td colspan="5">No failures</td></tr>{{end}}
+						</tbody>
+					</table>
+				</section>
+				<section id="warnings">
+					<h2>Warnings ({{warnings .Results}})</h2>
+					<table class="report-table">
+						{{template "header"}}
+						<tbody>
+						{{range $result := .Results}}{{range $detail := $result.WarningList}}{{template "row" (finding $result $detail)}}{{end}}{{end}}
+						{{if eq (warnings .Results) 0}}<tr class="empty"><td colspan="5">No warnings</td></tr>{{end}}
+						</tbody>
+					</table>
+				</section>
+				<section id="ignores">
+					<h2>Ignores ({{ignores .Results}})</h2>
+					<table class="report-table">
+						{{template "header"}}
+						<tbody>
+						{{range $result := .Results}}{{range $detail := $result.IgnoreList}}{{template "row" (finding $result $detail)}}{{end}}{{end}}
+						{{if eq (ignores .Results) 0}}<tr class="empty"><td colspan="5">No ignores</td></tr>{{end}}
+						</tbody>
+					</table>
+				</section>
 			</div>
 			<footer class="site-footer">
   				<div class="wrapper">
@@ -334,6 +421,76 @@ func getReportHTML() string {
     				<h2 class="footer-heading">&#9400; 2016&nbsp;ThoughtWorks, Inc.</h2>
   				</div>
 			</footer>
+			<script>
+				(function () {
+					var text = document.getElementById("filter-text");
+					var category = document.getElementById("filter-category");
+					var sections = document.querySelectorAll(".filter-section");
+					var rows = document.querySelectorAll("tr.finding");
+
+					var categories = {};
+					rows.forEach(function (row) { categories[row.dataset.category] = true; });
+					Object.keys(categories).sort().forEach(function (name) {
+						var option = document.createElement("option");
+						option.value = name;
+						option.textContent = name;
+						category.appendChild(option);
+					});
+
+					function applyFilters() {
+						var query = text.value.toLowerCase();
+						rows.forEach(function (row) {
+							var matches = row.textContent.toLowerCase().indexOf(query) !== -1 &&
+								(category.value === "" || row.dataset.category === category.value);
+							row.style.display = matches ? "" : "none";
+						});
+						sections.forEach(function (checkbox) {
+							document.getElementById(checkbox.value).style.display = checkbox.checked ? "" : "none";
+						});
+					}
+					text.addEventListener("input", applyFilters);
+					category.addEventListener("change", applyFilters);
+					sections.forEach(function (checkbox) { checkbox.addEventListener("change", applyFilters); });
+
+					document.querySelectorAll(".report-table th").forEach(function (header) {
+						header.addEventListener("click", function () {
+							var table = header.closest("table");
+							var body = table.querySelector("tbody");
+							var column = Array.prototype.indexOf.call(header.parentNode.children, header);
+							var ascending = header.getAttribute("aria-sort") !== "ascending";
+							table.querySelectorAll("th").forEach(function (th) { th.removeAttribute("aria-sort"); });
+							header.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+							Array.prototype.slice.call(body.querySelectorAll("tr.finding"))
+								.sort(function (a, b) {
+									var left = a.children[column].textContent.trim();
+									var right = b.children[column].textContent.trim();
+									return ascending ? left.localeCompare(right) : right.localeCompare(left);
+								})
+								.forEach(function (row) { body.appendChild(row); });
+						});
+					});
+				})();
+			</script>
 		</body>
-	</html>`
+	</html>
+{{define "header"}}
+						<thead>
+							<tr>
+								<th>File Path</th>
+								<th>Detector</th>
+								<th>Rule</th>
+								<th>Message</th>
+								<th>Found in Commit (Git SHAs)</th>
+							</tr>
+						</thead>
+{{end}}
+{{define "row"}}
+							<tr class="finding" data-category="{{.Category}}">
+								<td>{{.Filename}}{{if .OldFilename}}<span class="renamed">renamed from {{.OldFilename}}</span>{{end}}</td>
+								<td>{{.Category}}</td>
+								<td>{{.RuleID}}</td>
+								<td class="message">{{.Message}}{{if .Fingerprint}}<span class="fingerprint">fingerprint: {{.Fingerprint}}</span>{{end}}</td>
+								<td><ul class="commits">{{range .Commits}}<li>{{.}}</li>{{end}}</ul></td>
+							</tr>
+{{end}}`
 }
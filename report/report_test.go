@@ -0,0 +1,64 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLReportRendersFailuresWarningsAndIgnoresSeparately(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("secrets/id_rsa", "filename", "The file name secrets/id_rsa failed checks against the pattern ^.+_rsa$", []string{"c0ffee", "decade"})
+	results.FailWithSecret("config.yml", "filecontent", detector.Base64RuleID, "Expected file to not to contain base64 encoded texts such as: %s", "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", []string{"c0ffee"})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{"beef"})
+	results.Ignore("docs/README.md", "filecontent")
+
+	var html bytes.Buffer
+	err := writeHTMLReport(&html, results)
+
+	assert.NoError(t, err, "The template should render against the current results model")
+	report := html.String()
+	failures := section(report, "failures")
+	assert.Contains(t, failures, "Failures (2)")
+	assert.Contains(t, failures, "secrets/id_rsa")
+	assert.Contains(t, failures, "<li>c0ffee</li><li>decade</li>")
+	assert.Contains(t, failures, detector.Base64RuleID)
+	assert.NotContains(t, failures, "c3VwZXJzZWNyZXRwYXNzd29yZDEyMzQ1Njc4OTA=", "Secrets should only appear redacted")
+	warnings := section(report, "warnings")
+	assert.Contains(t, warnings, "Warnings (1)")
+	assert.Contains(t, warnings, "Expected .talismanrc to not to contain secrets")
+	assert.NotContains(t, warnings, "secrets/id_rsa")
+	ignores := section(report, "ignores")
+	assert.Contains(t, ignores, "Ignores (1)")
+	assert.Contains(t, ignores, "docs/README.md")
+	assert.Contains(t, report, `<span class="value">1</span>File name`)
+	assert.Contains(t, report, `<span class="value">1</span>File content`)
+}
+
+func TestHTMLReportRendersEmptyResults(t *testing.T) {
+	var html bytes.Buffer
+	err := writeHTMLReport(&html, detector.NewDetectionResults())
+
+	assert.NoError(t, err)
+	assert.Contains(t, html.String(), "No failures")
+	assert.Contains(t, html.String(), "No warnings")
+	assert.Contains(t, html.String(), "No ignores")
+}
+
+func TestHTMLReportDoesNotReferenceExternalAssets(t *testing.T) {
+	var html bytes.Buffer
+	writeHTMLReport(&html, detector.NewDetectionResults())
+
+	assert.NotContains(t, html.String(), "<link")
+	assert.NotContains(t, html.String(), "src=")
+}
+
+func section(report string, id string) string {
+	start := strings.Index(report, `<section id="`+id+`">`)
+	end := strings.Index(report[start:], "</section>")
+	return report[start : start+end]
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+//DefaultWidth is the width text is wrapped to when it is not written to a terminal and COLUMNS is not set
+const DefaultWidth = 120
+
+//isTerminal answers whether the writer is a terminal, as opposed to e.g. a pipe or a file
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+//colorsEnabled answers whether ANSI colours may be written to the writer, which is the case for terminals unless NO_COLOR is set (https://no-color.org)
+func colorsEnabled(w io.Writer) bool {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return !noColor && isTerminal(w)
+}
+
+//widthOf returns the number of columns text written to the writer should be wrapped to.
+//COLUMNS takes precedence over the size of the terminal.
+func widthOf(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if file, ok := w.(*os.File); ok && isTerminal(w) {
+		if width := terminalWidth(file); width > 0 {
+			return width
+		}
+	}
+	return DefaultWidth
+}
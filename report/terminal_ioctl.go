@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package report
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+//terminalWidth returns the number of columns of the terminal, or 0 if it cannot be told
+func terminalWidth(terminal *os.File) int {
+	var size struct {
+		rows, columns, xPixels, yPixels uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, terminal.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.columns)
+}
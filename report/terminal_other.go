@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package report
+
+import "os"
+
+//terminalWidth returns 0 as the size of terminals cannot be told on this platform, so COLUMNS or the DefaultWidth is used instead
+func terminalWidth(terminal *os.File) int {
+	return 0
+}
@@ -0,0 +1,45 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"talisman/detector"
+)
+
+//TextReporter writes a line per failure, warning and ignore, followed by a .talismanrc suggestion for the files with failures.
+//It writes neither tables nor colours, which makes its output easy to read in logs and to search.
+type TextReporter struct{}
+
+//Report writes the lines of the results
+func (TextReporter) Report(w io.Writer, r *detector.DetectionResults) error {
+	var out strings.Builder
+	for _, resultDetails := range r.Results {
+		for _, detail := range resultDetails.FailureList {
+			out.WriteString(textLine("FAIL", resultDetails, detail))
+		}
+		for _, detail := range resultDetails.WarningList {
+			out.WriteString(textLine("WARN", resultDetails, detail))
+		}
+		if len(resultDetails.IgnoreList) > 0 {
+			fmt.Fprintf(&out, "IGNORE %s: ignored by .talismanrc for %s\n", resultDetails.Filename, strings.Join(categoriesOf(resultDetails.IgnoreList), ", "))
+		}
+	}
+	if suggestion := r.TalismanRCSuggestion(); suggestion != "" {
+		out.WriteString("\nIf you are absolutely sure that you want to ignore the above files from talisman detectors, consider pasting the following format in .talismanrc file in the project root\n")
+		out.WriteString(suggestion)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func textLine(severity string, resultDetails detector.ResultsDetails, detail detector.Details) string {
+	location := string(resultDetails.Filename)
+	if detail.Line != 0 {
+		location = fmt.Sprintf("%s:%d", location, detail.Line)
+	}
+	if resultDetails.OldFilename != "" {
+		location = fmt.Sprintf("%s (from %s)", location, resultDetails.OldFilename)
+	}
+	return fmt.Sprintf("%s %s: [%s] %s (fingerprint %s)\n", severity, location, ruleOf(detail), strings.Replace(detail.Message, "\n", " ", -1), detail.Fingerprint)
+}
@@ -0,0 +1,26 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"talisman/detector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextReporterWritesALinePerFinding(t *testing.T) {
+	results := detector.NewDetectionResults()
+	results.Fail("some_file.pem", "filename", "Bomb", []string{})
+	results.Warn(".talismanrc", "filecontent", "Expected .talismanrc to not to contain secrets", []string{})
+	results.Ignore("docs/README.md", "filecontent")
+
+	var output bytes.Buffer
+	err := TextReporter{}.Report(&output, results)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `(?m)^FAIL some_file.pem: \[filename\] Bomb \(fingerprint [0-9a-f]+\)$`, output.String())
+	assert.Regexp(t, `(?m)^WARN .talismanrc: \[filecontent\] Expected .talismanrc to not to contain secrets`, output.String())
+	assert.Regexp(t, `(?m)^IGNORE docs/README.md: ignored by .talismanrc for filecontent$`, output.String())
+	assert.Contains(t, output.String(), "- filename: some_file.pem")
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"talisman/checksumcalculator"
 	"talisman/detector"
 	"talisman/git_repo"
 	"talisman/report"
 	"talisman/scanner"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
 )
 
 const (
@@ -20,13 +24,57 @@ const (
 
 //Runner represents a single run of the validations for a given commit range
 type Runner struct {
-	additions []git_repo.Addition
-	results   *detector.DetectionResults
+	additions    []git_repo.Addition
+	readErrors   []ReadError
+	results      *detector.DetectionResults
+	showSecrets  bool
+	plugins      bool
+	aggressive   bool
+	outputFormat string
+	outputFile   string
+	startedAt    time.Time
 }
 
 //NewRunner returns a new Runner.
 func NewRunner(additions []git_repo.Addition) *Runner {
-	return &Runner{additions, detector.NewDetectionResults()}
+	return &Runner{additions: additions, results: detector.NewDetectionResults(), startedAt: time.Now()}
+}
+
+//ShowSecrets makes the runner report matched secrets in full instead of redacting them. It is meant for local debugging only.
+func (r *Runner) ShowSecrets(show bool) *Runner {
+	r.showSecrets = show
+	return r
+}
+
+//WithPlugins makes the runner also run the plugins configured in .talismanrc, which must only be done when the .talismanrc can be trusted
+func (r *Runner) WithPlugins(plugins bool) *Runner {
+	r.plugins = plugins
+	return r
+}
+
+//Aggressive makes the file content detector also flag shorter texts that decode as base64, at the cost of more false positives
+func (r *Runner) Aggressive(aggressive bool) *Runner {
+	r.aggressive = aggressive
+	return r
+}
+
+//OutputFormat makes the runner print its results in the given output format instead of tables, e.g. for CI systems.
+//Results in a machine readable format are also printed as tables for people, to stderr when the machine readable ones go to stdout, so that both can be consumed.
+func (r *Runner) OutputFormat(format string) *Runner {
+	r.outputFormat = format
+	return r
+}
+
+//OutputFile makes the runner write its results in the output format to the file instead of stdout, printing them as tables to stderr like any other machine readable output
+func (r *Runner) OutputFile(file string) *Runner {
+	r.outputFile = file
+	return r
+}
+
+//ReadErrors makes the runner fail for every file that could not be read, as it could not be verified, and warn about those with nothing to verify
+func (r *Runner) ReadErrors(readErrors []ReadError) *Runner {
+	r.readErrors = readErrors
+	return r
 }
 
 //RunWithoutErrors will validate the commit range for errors and return either COMPLETED_SUCCESSFULLY or COMPLETED_WITH_ERRORS
@@ -36,15 +84,54 @@ func (r *Runner) RunWithoutErrors() int {
 	return r.exitStatus()
 }
 
-//Scan scans git commit history for potential secrets and returns 0 or 1 as exit code
-func (r *Runner) Scan(reportDirectory string) int {
+//RunInteractively behaves like RunWithoutErrors, but lets the user resolve the failures through the remediation.
+//Once the failures are resolved, the detectors are run again against the additions supplied by rerunAdditions.
+//Only the final results are written for tools, those of the first run are only shown to people.
+func (r *Runner) RunInteractively(remediation *InteractiveRemediation, rerunAdditions func() []git_repo.Addition) int {
+	r.doRun()
+	r.writeResults(r.progress(), r.humanFormat())
+	if !r.results.HasFailures() {
+		r.writeToolOutput()
+		return CompletedSuccessfully
+	}
+	if !remediation.Remediate(r.results) {
+		r.writeToolOutput()
+		return CompletedWithErrors
+	}
+	return NewRunner(rerunAdditions()).ShowSecrets(r.showSecrets).WithPlugins(r.plugins).Aggressive(r.aggressive).OutputFormat(r.outputFormat).OutputFile(r.outputFile).RunWithoutErrors()
+}
+
+//Scan scans the part of the git commit history selected by the scanner options for potential secrets and returns 0 or 1 as exit code
+func (r *Runner) Scan(reportDirectory string, scanOptions scanner.Options) int {
 
-	fmt.Println("Please wait while talisman scans entire repository including the git history...")
-	additions := scanner.GetAdditions()
-	ignores := detector.TalismanRCIgnore{}
-	detector.DefaultChain().Test(additions, ignores, r.results)
+	fmt.Fprintln(r.progress(), "Please wait while talisman scans entire repository including the git history...")
+	talismanRC, _ := readRepoFile()(detector.DefaultRCFileName)
+	talismanRCIgnore := r.talismanRCIgnore(talismanRC)
+	//the history is scanned regardless of the files ignored today, but the rest of the .talismanrc still applies
+	ignores := talismanRCIgnore
+	ignores.FileIgnoreConfig = nil
+	r.results.SetRedactionPolicy(r.redactionPolicy(talismanRCIgnore))
+	//secrets shown in full must never be persisted in the cache
+	if scanOptions.NoCache || r.showSecrets {
+		additions := scanner.GetAdditions(scanOptions)
+		r.chain(talismanRCIgnore).Test(additions, ignores, r.results)
+	} else {
+		wd, _ := os.Getwd()
+		cache := scanner.LoadCache(scanner.CachePath(git_repo.RepoContaining(wd)), scanner.RulesetVersion(Version, talismanRC, r.aggressive, r.pluginsRun(talismanRCIgnore)))
+		scanner.TestWithCache(scanOptions, r.chain(talismanRCIgnore), ignores, r.results, cache)
+		if err := cache.Save(); err != nil {
+			log.Errorf("Unable to save the scan cache: %v", err)
+		}
+		statistics := cache.Statistics()
+		fmt.Fprintf(r.progress(), "Scan cache: %d hits, %d misses (%d invalidated), %d entries\n", statistics.Hits, statistics.Misses, statistics.Invalidated, statistics.Entries)
+	}
 	reportsPath := report.GenerateReport(r.results, reportDirectory)
-	fmt.Printf("Please check %s folder for the talisman scan report", reportsPath)
+	fmt.Fprintf(r.progress(), "Please check %s folder for the talisman scan report", reportsPath)
+	//the findings of a scan are only printed in formats asked for, as the tables would be too long
+	if r.outputFile != "" || (r.outputFormat != "" && r.outputFormat != report.TableFormat) {
+		fmt.Fprintln(r.progress())
+		r.writeOutput()
+	}
 	return r.exitStatus()
 }
 
@@ -61,17 +148,108 @@ func (r *Runner) RunChecksumCalculator(fileNamePatterns []string) int {
 }
 
 func (r *Runner) doRun() {
-	ignoresNew := detector.ReadConfigFromRCFile(readRepoFile())
-	detector.DefaultChain().Test(r.additions, ignoresNew, r.results)
+	talismanRC, _ := readRepoFile()(detector.DefaultRCFileName)
+	ignoresNew := r.talismanRCIgnore(talismanRC)
+	r.results.SetRedactionPolicy(r.redactionPolicy(ignoresNew))
+	r.chain(ignoresNew).Test(r.additions, ignoresNew, r.results)
+	for _, readError := range r.readErrors {
+		if readError.Warning {
+			r.results.Warn(git_repo.FilePath(readError.Path), detector.ErrorCategory, readError.String(), []string{})
+			continue
+		}
+		r.results.Fail(git_repo.FilePath(readError.Path), detector.ErrorCategory, readError.String(), []string{})
+	}
+}
+
+//talismanRCIgnore parses the .talismanrc and fails the run on it if it cannot be parsed, as the files could not be verified the way it asks for
+func (r *Runner) talismanRCIgnore(talismanRC []byte) detector.TalismanRCIgnore {
+	talismanRCIgnore, err := detector.ParseTalismanRCIgnore(talismanRC)
+	if err != nil {
+		r.results.Fail(git_repo.FilePath(detector.DefaultRCFileName), detector.ErrorCategory, fmt.Sprintf("Unable to parse %s: %v", detector.DefaultRCFileName, err), []string{})
+	}
+	return talismanRCIgnore
 }
 
+func (r *Runner) chain(rc detector.TalismanRCIgnore) *detector.Chain {
+	chain := detector.DefaultChain()
+	if r.aggressive {
+		chain = detector.AggressiveChain()
+	}
+	if !r.plugins {
+		return chain
+	}
+	return chain.AddPlugins(rc)
+}
+
+//pluginsRun returns the plugins configured in .talismanrc the runner runs, none unless plugins are enabled
+func (r *Runner) pluginsRun(rc detector.TalismanRCIgnore) []detector.PluginConfig {
+	if !r.plugins {
+		return nil
+	}
+	return rc.Plugins
+}
+
+func (r *Runner) redactionPolicy(rc detector.TalismanRCIgnore) detector.RedactionPolicy {
+	policy := rc.Redaction
+	policy.ShowSecrets = r.showSecrets
+	return policy
+}
+
+//printReport shows the results to people and writes them for tools, if the output format or file asks for it
 func (r *Runner) printReport() {
-	if r.results.HasWarnings() {
-		fmt.Println(r.results.ReportWarnings())
+	r.writeResults(r.progress(), r.humanFormat())
+	r.writeToolOutput()
+}
+
+//writeToolOutput writes the results in the output format when it is not the one already shown to people, i.e. when it is machine readable or goes to a file
+func (r *Runner) writeToolOutput() {
+	if r.writesToolOutput() {
+		r.writeOutput()
 	}
-	if r.results.HasIgnores() || r.results.HasFailures() {
-		fmt.Println(r.results.Report())
+}
+
+//writesToolOutput states whether the results are written for tools besides being shown to people
+func (r *Runner) writesToolOutput() bool {
+	return r.outputFile != "" || report.IsMachineReadable(r.outputFormat)
+}
+
+//writeOutput writes the results in the output format, to the output file if there is one and to stdout otherwise
+func (r *Runner) writeOutput() {
+	if r.outputFile == "" {
+		r.writeResults(os.Stdout, r.outputFormat)
+	} else if file, err := os.Create(r.outputFile); err != nil {
+		log.Errorf("Unable to create %s: %v", r.outputFile, err)
+	} else {
+		r.writeResults(file, r.outputFormat)
+		file.Close()
+	}
+}
+
+//humanFormat is the format the results are shown to people in, tables unless a human readable output format is asked for
+func (r *Runner) humanFormat() string {
+	if report.IsMachineReadable(r.outputFormat) {
+		return report.TableFormat
+	}
+	return r.outputFormat
+}
+
+func (r *Runner) writeResults(w io.Writer, format string) {
+	reporter, err := report.ReporterFor(format, report.Run{Version: Version, Start: r.startedAt, End: time.Now()})
+	if err == nil {
+		err = reporter.Report(w, r.results)
+	}
+	if err != nil {
+		log.Errorf("Unable to write the results: %v", err)
+	}
+}
+
+//progress returns where messages about the progress of a run and the tables for people go, which is stderr whenever results are written for tools,
+//so that the output meant for people goes to the same place however the results for tools are consumed
+func (r *Runner) progress() io.Writer {
+	if r.writesToolOutput() {
+		return os.Stderr
 	}
+	return os.Stdout
 }
 
 func (r *Runner) exitStatus() int {
@@ -83,6 +261,6 @@ func (r *Runner) exitStatus() int {
 
 func readRepoFile() func(string) ([]byte, error) {
 	wd, _ := os.Getwd()
-	repo := git_repo.RepoLocatedAt(wd)
+	repo := git_repo.RepoContaining(wd)
 	return repo.ReadRepoFileOrNothing
 }
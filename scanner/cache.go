@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"talisman/detector"
+	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// CacheFileName is the name of the file, within the talisman folder of the git directory, the scan cache is persisted in
+const CacheFileName = "scan-cache.json"
+
+// Cache remembers the findings for every blob scanned so far, keyed by blob and path and then by ruleset version.
+// As blobs are immutable, a blob that was scanned with the same ruleset does not need to be scanned again.
+type Cache struct {
+	path           string
+	rulesetVersion string
+	entries        map[string]map[string]detector.ResultsDetails
+	statistics     CacheStatistics
+}
+
+// CacheStatistics counts how the cache was used during a scan
+type CacheStatistics struct {
+	Hits        int
+	Misses      int
+	Invalidated int
+	Entries     int
+}
+
+// CachePath returns the location of the scan cache of the repository. Blobs are shared by all the worktrees of a repository and so is the cache.
+func CachePath(repo git_repo.GitRepo) string {
+	return filepath.Join(repo.CommonDir(), "talisman", CacheFileName)
+}
+
+// RulesetVersion identifies the rules a scan runs with. Any change to the detectors, the built-in exclusions, the talisman version, the .talismanrc,
+// the aggressive mode, the plugins run or their executables yields a new version.
+func RulesetVersion(talismanVersion string, talismanRC []byte, aggressive bool, plugins []detector.PluginConfig) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(detector.RulesVersion + "\x00" + detector.ExclusionsVersion + "\x00" + talismanVersion + "\x00"))
+	if aggressive {
+		hasher.Write([]byte("aggressive\x00"))
+	}
+	for _, plugin := range plugins {
+		hasher.Write([]byte("plugin\x00" + plugin.Name + "\x00" + executableHash(plugin.Command) + "\x00"))
+	}
+	hasher.Write(talismanRC)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// executableHash returns the sha256 hash of the executable the command runs, or an empty string if it cannot be found or read
+func executableHash(command string) string {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return ""
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:])
+}
+
+// LoadCache reads the cache persisted at path. A missing or unreadable cache yields an empty one.
+func LoadCache(path string, rulesetVersion string) *Cache {
+	cache := &Cache{path: path, rulesetVersion: rulesetVersion, entries: map[string]map[string]detector.ResultsDetails{}}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		log.Printf("Ignoring unreadable scan cache %s: %v", path, err)
+		cache.entries = map[string]map[string]detector.ResultsDetails{}
+	}
+	return cache
+}
+
+// Lookup returns the findings cached for the blob of the addition, provided it was scanned with the current ruleset
+func (c *Cache) Lookup(addition git_repo.Addition) (detector.ResultsDetails, bool) {
+	versions, ok := c.entries[cacheKey(addition)]
+	if !ok {
+		c.statistics.Misses++
+		return detector.ResultsDetails{}, false
+	}
+	findings, ok := versions[c.rulesetVersion]
+	if !ok {
+		c.statistics.Misses++
+		c.statistics.Invalidated++
+		return detector.ResultsDetails{}, false
+	}
+	c.statistics.Hits++
+	return findings, true
+}
+
+// Store caches the findings for the blob of the addition, replacing the findings of any other ruleset
+func (c *Cache) Store(addition git_repo.Addition, findings detector.ResultsDetails) {
+	c.entries[cacheKey(addition)] = map[string]detector.ResultsDetails{c.rulesetVersion: findings}
+}
+
+// Save persists the cache
+func (c *Cache) Save() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0644)
+}
+
+// Statistics returns how the cache was used so far
+func (c *Cache) Statistics() CacheStatistics {
+	statistics := c.statistics
+	statistics.Entries = len(c.entries)
+	return statistics
+}
+
+func cacheKey(addition git_repo.Addition) string {
+	return addition.Blob + "\t" + string(addition.Path)
+}
+
+// TestWithCache runs the chain against the part of the git history selected by the options and collects the findings in results.
+// Blobs found in the cache are neither read nor scanned again, their cached findings are reported instead.
+// Blobs a plugin failed on are not cached, so that they are scanned again once the plugin works.
+func TestWithCache(options Options, chain detector.Detector, ignores detector.TalismanRCIgnore, results *detector.DetectionResults, cache *Cache) {
+	blobsInCommits := getBlobsInCommit(options)
+	for blob, commits := range blobsInCommits.commits {
+		addition := blobAddition(blob, commits)
+		findings, ok := cache.Lookup(addition)
+		if !ok {
+			addition.Data = getData(blobsInCommits.directories[blob], addition.Blob)
+			var complete bool
+			findings, complete = findingsFor(addition, chain, ignores, results)
+			if complete {
+				cache.Store(addition, findings)
+			}
+		}
+		results.Merge(&detector.DetectionResults{Results: []detector.ResultsDetails{withCommits(findings, commits)}})
+		results.Scanned(addition.Path)
+	}
+}
+
+// findingsFor scans the blob of the addition and returns its findings, and false if they are incomplete as a detector failed on it.
+// A failing detector reports the failure on the .talismanrc rather than on the blob, that failure is recorded in results once however many blobs it failed on.
+func findingsFor(addition git_repo.Addition, chain detector.Detector, ignores detector.TalismanRCIgnore, results *detector.DetectionResults) (detector.ResultsDetails, bool) {
+	blobResults := detector.NewDetectionResults()
+	blobResults.SetRedactionPolicy(results.RedactionPolicy())
+	chain.Test([]git_repo.Addition{addition}, ignores, blobResults)
+	findings := detector.ResultsDetails{Filename: addition.Path}
+	complete := true
+	for _, resultDetails := range blobResults.Results {
+		if resultDetails.Filename != addition.Path {
+			complete = false
+			recordOnce(results, resultDetails)
+			continue
+		}
+		findings.FailureList = append(findings.FailureList, resultDetails.FailureList...)
+		findings.WarningList = append(findings.WarningList, resultDetails.WarningList...)
+		findings.IgnoreList = append(findings.IgnoreList, resultDetails.IgnoreList...)
+	}
+	return withCommits(findings, nil), complete
+}
+
+// recordOnce adds the failures and warnings to results unless they were already recorded, e.g. as the same detector failed on an earlier blob
+func recordOnce(results *detector.DetectionResults, resultDetails detector.ResultsDetails) {
+	recorded := map[string]bool{}
+	for _, existing := range results.Results {
+		if existing.Filename == resultDetails.Filename {
+			for _, details := range append(append([]detector.Details{}, existing.FailureList...), existing.WarningList...) {
+				recorded[details.Fingerprint] = true
+			}
+		}
+	}
+	unrecorded := detector.ResultsDetails{Filename: resultDetails.Filename}
+	for _, details := range resultDetails.FailureList {
+		if !recorded[details.Fingerprint] {
+			unrecorded.FailureList = append(unrecorded.FailureList, details)
+		}
+	}
+	for _, details := range resultDetails.WarningList {
+		if !recorded[details.Fingerprint] {
+			unrecorded.WarningList = append(unrecorded.WarningList, details)
+		}
+	}
+	results.Merge(&detector.DetectionResults{Results: []detector.ResultsDetails{unrecorded}})
+}
+
+func withCommits(findings detector.ResultsDetails, commits []string) detector.ResultsDetails {
+	result := detector.ResultsDetails{Filename: findings.Filename}
+	result.FailureList = detailsWithCommits(findings.FailureList, commits)
+	result.WarningList = detailsWithCommits(findings.WarningList, commits)
+	result.IgnoreList = detailsWithCommits(findings.IgnoreList, commits)
+	return result
+}
+
+func detailsWithCommits(detailsList []detector.Details, commits []string) []detector.Details {
+	result := make([]detector.Details, len(detailsList))
+	for i, details := range detailsList {
+		details.Commits = append([]string{}, commits...)
+		result[i] = details
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"talisman/detector"
+	"talisman/git_testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	git_testing.Logger = logrus.WithField("Environment", "Debug")
+}
+
+func TestBlobsAPluginFailedOnAreNotCached(t *testing.T) {
+	withRepoOfTwoFiles(func(root string) {
+		options := Options{directory: root}
+		cache := LoadCache(filepath.Join(root, ".git", "talisman", CacheFileName), "version")
+		failing := detector.NewPluginDetector(detector.PluginConfig{Name: "crashing", Command: "sh", Args: []string{"-c", "exit 1"}})
+
+		results := detector.NewDetectionResults()
+		TestWithCache(options, failing, detector.TalismanRCIgnore{}, results, cache)
+
+		assert.Equal(t, 0, cache.Statistics().Entries, "Expected no blob to be cached as the plugin failed on all of them")
+		assert.Len(t, results.Results, 1, "Expected the failure to be reported on the .talismanrc only")
+		assert.Equal(t, detector.DefaultRCFileName, string(results.Results[0].Filename))
+		assert.Len(t, results.Results[0].WarningList, 1, "Expected the failure to be reported once")
+
+		working := detector.NewPluginDetector(detector.PluginConfig{Name: "crashing", Command: "sh", Args: []string{"-c", "cat > /dev/null"}})
+		results = detector.NewDetectionResults()
+		TestWithCache(options, working, detector.TalismanRCIgnore{}, results, cache)
+
+		assert.False(t, results.HasWarnings(), "Expected the failure to not be replayed from the cache")
+		assert.Equal(t, 2, cache.Statistics().Entries, "Expected the blobs to be cached once the plugin works")
+	})
+}
+
+func withRepoOfTwoFiles(operation func(root string)) {
+	root, err := ioutil.TempDir(os.TempDir(), "talisman-cache-test")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(root)
+	git := git_testing.Init(root)
+	git.CreateFileWithContents("readme.txt", "nothing to see here")
+	git.CreateFileWithContents("notes.txt", "nothing here either")
+	git.AddAndcommit("*", "initial commit")
+	operation(root)
+}
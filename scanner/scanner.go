@@ -3,79 +3,297 @@ package scanner
 import (
 	"log"
 	"os/exec"
+	"path"
+	"strconv"
 	"strings"
 	"talisman/git_repo"
+
+	"github.com/bmatcuk/doublestar"
 )
 
 // BlobsInCommits is a map of blob and list of the commits the blobs is present in.
 type BlobsInCommits struct {
 	commits map[string][]string
+	// directories holds the directory of the submodule a blob was found in, blobs of the scanned repository have none
+	directories map[string]string
+}
+
+// Options restricts the part of the git history that is scanned. The zero value scans every commit reachable from any ref.
+type Options struct {
+	// Revisions are the refs and revision ranges (e.g. main, origin/main..HEAD) to walk instead of all refs
+	Revisions []string
+	// Since and Until limit the walk to commits more recent or older than a date, in any format understood by git log
+	Since string
+	Until string
+	// IncludePaths and ExcludePaths are glob patterns restricting the files that are scanned
+	IncludePaths []string
+	ExcludePaths []string
+	// MaxCommits limits the number of commits walked, 0 meaning no limit
+	MaxCommits int
+	// Scopes are additional objects to scan: StashScope, ReflogScope and UnreachableScope
+	Scopes []string
+	// NoCache scans every blob again instead of reusing the findings cached by previous scans
+	NoCache bool
+	// RecurseSubmodules scans the history of every checked out submodule as well, reporting its files prefixed with the submodule path
+	RecurseSubmodules bool
+
+	// directory is the repository the git commands run in, the current directory when empty
+	directory string
+	// submodulePath is the path of the submodule being scanned, the paths of its blobs are prefixed with it
+	submodulePath string
 }
 
-// GetAdditions will get all the additions for entire git history
-func GetAdditions() []git_repo.Addition {
-	blobsInCommits := getBlobsInCommit()
+// GetAdditions will get all the additions for the part of the git history selected by the options
+func GetAdditions(options Options) []git_repo.Addition {
+	blobsInCommits := getBlobsInCommit(options)
 	var additions []git_repo.Addition
-	for blob := range blobsInCommits.commits {
-		objectDetails := strings.Split(blob, "\t")
-		objectHash := objectDetails[0]
-		data := getData(objectHash)
-		filePath := objectDetails[1]
-		newAddition := git_repo.NewScannerAddition(filePath, blobsInCommits.commits[blob], data)
+	for blob, commits := range blobsInCommits.commits {
+		newAddition := blobAddition(blob, commits)
+		newAddition.Data = getData(blobsInCommits.directories[blob], newAddition.Blob)
 		additions = append(additions, newAddition)
 	}
 	return additions
 }
 
-func getBlobsInCommit() BlobsInCommits {
-	commits := getAllCommits()
+func blobAddition(blob string, commits []string) git_repo.Addition {
+	objectDetails := strings.Split(blob, "\t")
+	objectHash := objectDetails[0]
+	filePath := objectDetails[1]
+	newAddition := git_repo.NewScannerAddition(filePath, commits, nil)
+	newAddition.Blob = objectHash
+	return newAddition
+}
+
+func getBlobsInCommit(options Options) BlobsInCommits {
 	blobsInCommits := newBlobsInCommit()
+	addBlobsInCommits(blobsInCommits, options)
+	return blobsInCommits
+}
+
+func addBlobsInCommits(blobsInCommits BlobsInCommits, options Options) {
+	unreachable := unreachableObjects(options)
+	commits := getScannedCommits(options, unreachable)
 	result := make(chan []string, len(commits))
 	for _, commit := range commits {
-		go putBlobsInChannel(commit, result)
+		go putBlobsInChannel(options, commit, result)
 	}
-	for i := 1; i < len(commits); i++ {
-		getBlobsFromChannel(blobsInCommits, result)
+	for i := 0; i < len(commits); i++ {
+		getBlobsFromChannel(blobsInCommits, result, options)
+	}
+	addUnreachableBlobs(blobsInCommits, unreachable["blob"], options)
+	if options.RecurseSubmodules {
+		for _, submoduleOptions := range options.submodules(commits) {
+			addBlobsInCommits(blobsInCommits, submoduleOptions)
+		}
 	}
-	return blobsInCommits
 }
 
-func putBlobsInChannel(commit string, result chan []string) {
-	if commit != "" {
-		blobDetailsBytes, _ := exec.Command("git", "ls-tree", "-r", commit).CombinedOutput()
-		blobDetailsList := strings.Split(string(blobDetailsBytes), "\n")
-		blobDetailsList = append(blobDetailsList, commit)
-		result <- blobDetailsList
+// submodules returns the options scanning the history of every checked out submodule.
+// A full-history scan walks all the refs of a submodule, while a restricted scan only walks the submodule commits recorded by the scanned commits of the superproject,
+// one walk per recorded range. The path filters of the superproject do not apply to the walk of a submodule, its blobs are filtered by their prefixed path.
+func (options Options) submodules(commits []scannedCommit) []Options {
+	repo := git_repo.RepoContaining(options.directory)
+	var ranges map[string][]string
+	if options.isRestricted() {
+		ranges = submoduleRanges(repo, commits)
 	}
+	var result []Options
+	for _, submodulePath := range repo.CheckedOutSubmodules() {
+		submoduleOptions := options
+		submoduleOptions.Revisions = nil
+		submoduleOptions.directory = repo.Submodule(submodulePath).Root()
+		submoduleOptions.submodulePath = path.Join(options.submodulePath, submodulePath)
+		if !options.isRestricted() {
+			result = append(result, submoduleOptions)
+			continue
+		}
+		submoduleOptions.Since, submoduleOptions.Until, submoduleOptions.MaxCommits = "", "", 0
+		for _, revisionRange := range ranges[submodulePath] {
+			submoduleOptions.Revisions = []string{revisionRange}
+			result = append(result, submoduleOptions)
+		}
+	}
+	return result
+}
+
+// submoduleRanges returns the ranges of submodule commits the scanned commits recorded, by the path of their submodule.
+// A submodule added by a commit contributes its whole history up to the recorded commit. Recorded commits that have not been fetched are skipped.
+func submoduleRanges(repo git_repo.GitRepo, commits []scannedCommit) map[string][]string {
+	ranges := map[string][]string{}
+	seen := map[string]bool{}
+	for _, commit := range commits {
+		parents := strings.Fields(strings.Join(gitLines(repo.Root(), "rev-list", "--parents", "-n", "1", commit.hash), ""))
+		if len(parents) < 2 {
+			parents = append(parents, git_repo.EmptyTreeSha)
+		}
+		for _, parent := range parents[1:] {
+			for _, change := range repo.ChangedSubmodules(parent, commit.hash) {
+				submodule := repo.Submodule(change.Path)
+				if !submodule.HasCommit(change.NewCommit) {
+					continue
+				}
+				revisionRange := change.NewCommit
+				if !change.IsNew() && submodule.HasCommit(change.OldCommit) {
+					revisionRange = change.OldCommit + ".." + change.NewCommit
+				}
+				if !seen[change.Path+"\t"+revisionRange] {
+					seen[change.Path+"\t"+revisionRange] = true
+					ranges[change.Path] = append(ranges[change.Path], revisionRange)
+				}
+			}
+		}
+	}
+	return ranges
 }
 
-func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan []string) {
+// putBlobsInChannel sends the blobs of the commit in the format of git ls-tree, followed by the commit.
+// The commits of a restricted walk only contribute the blobs they changed, so that e.g. scanning the commits of a pull request does not report the files it never touched.
+func putBlobsInChannel(options Options, commit scannedCommit, result chan []string) {
+	var blobDetailsList []string
+	if options.isRestricted() && commit.origin == "" {
+		blobDetailsList = changedBlobs(options.directory, commit.hash)
+	} else {
+		command := exec.Command("git", "ls-tree", "-r", commit.hash)
+		command.Dir = options.directory
+		blobDetailsBytes, _ := command.CombinedOutput()
+		blobDetailsList = strings.Split(string(blobDetailsBytes), "\n")
+	}
+	blobDetailsList = append(blobDetailsList, commit.String())
+	result <- blobDetailsList
+}
+
+// changedBlobs returns the blobs the commit added or modified, in the format of git ls-tree.
+// The blobs of a merge are those that differ from all of its parents, i.e. the changes made by the merge itself.
+func changedBlobs(directory string, commit string) []string {
+	var blobs []string
+	for _, line := range gitLines(directory, "diff-tree", "-r", "-c", "--root", "--no-commit-id", commit) {
+		tab := strings.Index(line, "\t")
+		if !strings.HasPrefix(line, ":") || tab < 0 {
+			continue
+		}
+		//the modes of the parents and of the commit are followed by as many object names and the status
+		fields := strings.Fields(line[:tab])
+		mode := strings.TrimLeft(fields[(len(fields)-1)/2-1], ":")
+		if mode == "000000" || mode == "160000" {
+			//deleted files and submodules have no blob in the commit
+			continue
+		}
+		blobs = append(blobs, mode+" blob "+fields[len(fields)-2]+"\t"+line[tab+1:])
+	}
+	return blobs
+}
+
+func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan []string, options Options) {
 	blobs := <-result
 	commit := blobs[len(blobs)-1]
 	for _, blob := range blobs[:len(blobs)] {
 		if blob != "" && blob != commit {
 			blobDetailsString := strings.Split(blob, " ")
+			if blobDetailsString[1] != "blob" {
+				continue
+			}
 			blobDetails := strings.Split(blobDetailsString[2], "	")
-			blobHash := blobDetails[0] + "\t" + blobDetails[1]
+			blobPath := path.Join(options.submodulePath, blobDetails[1])
+			if !options.includesPath(blobPath) {
+				continue
+			}
+			blobHash := blobDetails[0] + "\t" + blobPath
+			if containsCommit(blobsInCommits.commits[blobHash], commit) {
+				//the ranges walked in a submodule may overlap
+				continue
+			}
 			blobsInCommits.commits[blobHash] = append(blobsInCommits.commits[blobHash], commit)
+			if options.directory != "" {
+				blobsInCommits.directories[blobHash] = options.directory
+			}
 		}
 	}
 }
 
-func getAllCommits() []string {
-	out, err := exec.Command("git", "log", "--all", "--pretty=%H").CombinedOutput()
+func containsCommit(commits []string, commit string) bool {
+	for _, c := range commits {
+		if c == commit {
+			return true
+		}
+	}
+	return false
+}
+
+func getAllCommits(options Options) []string {
+	command := exec.Command("git", options.logArgs()...)
+	command.Dir = options.directory
+	out, err := command.CombinedOutput()
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal(err, string(out))
+	}
+	var commits []string
+	for _, commit := range strings.Split(string(out), "\n") {
+		if commit != "" {
+			commits = append(commits, commit)
+		}
+	}
+	return commits
+}
+
+func (options Options) logArgs() []string {
+	args := []string{"log", "--pretty=%H"}
+	if options.MaxCommits > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(options.MaxCommits))
+	}
+	if options.Since != "" {
+		args = append(args, "--since="+options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until="+options.Until)
+	}
+	if len(options.Revisions) == 0 {
+		args = append(args, "--all")
+	} else {
+		args = append(args, options.Revisions...)
+	}
+	args = append(args, "--")
+	if options.submodulePath != "" {
+		return args
+	}
+	for _, include := range options.IncludePaths {
+		args = append(args, ":(glob)"+include)
+	}
+	for _, exclude := range options.ExcludePaths {
+		args = append(args, ":(glob,exclude)"+exclude)
+	}
+	return args
+}
+
+// isRestricted answers whether the walk is restricted to some of the commits, rather than walking all of them
+func (options Options) isRestricted() bool {
+	return len(options.Revisions) > 0 || options.Since != "" || options.Until != "" || options.MaxCommits > 0
+}
+
+func (options Options) includesPath(filePath string) bool {
+	for _, exclude := range options.ExcludePaths {
+		if matched, _ := doublestar.Match(exclude, filePath); matched {
+			return false
+		}
+	}
+	if len(options.IncludePaths) == 0 {
+		return true
+	}
+	for _, include := range options.IncludePaths {
+		if matched, _ := doublestar.Match(include, filePath); matched {
+			return true
+		}
 	}
-	return strings.Split(string(out), "\n")
+	return false
 }
 
-func getData(objectHash string) []byte {
-	out, _ := exec.Command("git", "cat-file", "-p", objectHash).CombinedOutput()
+func getData(directory string, objectHash string) []byte {
+	command := exec.Command("git", "cat-file", "-p", objectHash)
+	command.Dir = directory
+	out, _ := command.CombinedOutput()
 	return out
 }
 
 func newBlobsInCommit() BlobsInCommits {
 	commits := make(map[string][]string)
-	return BlobsInCommits{commits: commits}
+	return BlobsInCommits{commits: commits, directories: map[string]string{}}
 }
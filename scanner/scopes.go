@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"log"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// Scopes of objects the scanner can scan in addition to the commits reachable from the scanned refs
+const (
+	// StashScope scans every stash entry, including its index and untracked files commits
+	StashScope = "stash"
+	// ReflogScope scans the commits that are only reachable from reflog entries, e.g. amended or reset commits
+	ReflogScope = "reflog"
+	// UnreachableScope scans the commits and blobs that are not reachable from any ref or reflog entry
+	UnreachableScope = "unreachable"
+)
+
+// unreachableBlobOrigin labels the blobs that are not part of any scanned commit
+const unreachableBlobOrigin = "unreachable blob"
+
+// scannedCommit is a commit the scanner walks, along with how it was found when it is not reachable from the scanned refs
+type scannedCommit struct {
+	hash   string
+	origin string
+}
+
+// String identifies the commit in findings, labelled with its origin when it has one
+func (c scannedCommit) String() string {
+	if c.origin == "" {
+		return c.hash
+	}
+	return c.hash + " (" + c.origin + ")"
+}
+
+func (options Options) hasScope(scope string) bool {
+	for _, s := range options.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (options Options) validateScopes() {
+	for _, scope := range options.Scopes {
+		if scope != StashScope && scope != ReflogScope && scope != UnreachableScope {
+			log.Fatalf("Unknown scan scope %q, expected one of %s, %s or %s", scope, StashScope, ReflogScope, UnreachableScope)
+		}
+	}
+}
+
+// getScannedCommits returns the commits selected by the options followed by the commits of the additional scopes.
+// A commit found in several scopes is labelled with the most specific one.
+func getScannedCommits(options Options, unreachable map[string][]string) []scannedCommit {
+	options.validateScopes()
+	var commits []scannedCommit
+	indices := map[string]int{}
+	add := func(commit scannedCommit) {
+		if i, ok := indices[commit.hash]; ok {
+			commits[i] = commit
+			return
+		}
+		indices[commit.hash] = len(commits)
+		commits = append(commits, commit)
+	}
+	for _, commit := range getAllCommits(options) {
+		add(scannedCommit{hash: commit})
+	}
+	if options.hasScope(ReflogScope) {
+		for _, commit := range gitLines(options.directory, "rev-list", "--reflog", "--not", "--all") {
+			add(scannedCommit{hash: commit, origin: "reflog"})
+		}
+	}
+	if options.hasScope(StashScope) {
+		for _, commit := range stashCommits(options.directory) {
+			add(commit)
+		}
+	}
+	for _, commit := range unreachable["commit"] {
+		add(scannedCommit{hash: commit, origin: UnreachableScope})
+	}
+	return commits
+}
+
+// stashCommits returns the commit of every stash entry along with the commits holding its index and untracked files
+func stashCommits(directory string) []scannedCommit {
+	var commits []scannedCommit
+	for _, entry := range gitLines(directory, "stash", "list", "--pretty=%H %gd") {
+		fields := strings.SplitN(entry, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		commits = append(commits, scannedCommit{hash: fields[0], origin: fields[1]})
+		//a stash commit has the stashed HEAD, the index and optionally the untracked files as parents
+		parents := strings.Fields(strings.Join(gitLines(directory, "rev-list", "--parents", "-n", "1", fields[0]), ""))
+		for i, label := range []string{"index", "untracked"} {
+			if len(parents) > i+2 {
+				commits = append(commits, scannedCommit{hash: parents[i+2], origin: fields[1] + " " + label})
+			}
+		}
+	}
+	return commits
+}
+
+// unreachableObjects returns the hashes of the objects no ref or reflog entry points to, by type
+func unreachableObjects(options Options) map[string][]string {
+	objects := map[string][]string{}
+	if !options.hasScope(UnreachableScope) {
+		return objects
+	}
+	for _, line := range gitLines(options.directory, "fsck", "--unreachable", "--no-progress") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "unreachable" {
+			objects[fields[1]] = append(objects[fields[1]], fields[2])
+		}
+	}
+	return objects
+}
+
+// addUnreachableBlobs adds the unreachable blobs that are not part of any scanned commit. As their path is unknown, they are named after their hash.
+func addUnreachableBlobs(blobsInCommits BlobsInCommits, blobs []string, options Options) {
+	scanned := map[string]bool{}
+	for blob := range blobsInCommits.commits {
+		scanned[strings.Split(blob, "\t")[0]] = true
+	}
+	for _, blob := range blobs {
+		blobPath := path.Join(options.submodulePath, blob)
+		if scanned[blob] || !options.includesPath(blobPath) {
+			continue
+		}
+		blobsInCommits.commits[blob+"\t"+blobPath] = []string{unreachableBlobOrigin}
+		if options.directory != "" {
+			blobsInCommits.directories[blob+"\t"+blobPath] = options.directory
+		}
+	}
+}
+
+func gitLines(directory string, args ...string) []string {
+	command := exec.Command("git", args...)
+	command.Dir = directory
+	out, _ := command.Output()
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"talisman/detector"
+	"talisman/git_repo"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	//DefaultListenAddress is the address talisman serve listens on unless another one is supplied
+	DefaultListenAddress = "127.0.0.1:8080"
+	//DefaultMaxRequestSize is the largest request body talisman serve accepts, in bytes
+	DefaultMaxRequestSize = 10 * 1024 * 1024
+)
+
+//ScanFile is a file of a file set sent to the scan endpoint of the server
+type ScanFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+//FilesRequest is the body of requests to scan a file set
+type FilesRequest struct {
+	Files []ScanFile `json:"files"`
+	//TalismanRC is the content of the .talismanrc applied to the files, plugins configured in it are never run
+	TalismanRC string `json:"talismanrc,omitempty"`
+}
+
+//RangeRequest is the body of requests to scan a commit range of a repository on the machine the server runs on.
+//The .talismanrc committed in To is applied, plugins configured in it are never run.
+type RangeRequest struct {
+	Repository string `json:"repository"`
+	//From is the commit the range starts after, the range includes every file of To when it is empty
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+//Server exposes the detectors over HTTP. Every scan responds with the same results as report.json.
+//At most maxConcurrentScans scans run at the same time, further requests wait for one of them to finish.
+//As anyone able to reach the server controls the .talismanrc applied, the plugins configured in it are never run.
+type Server struct {
+	maxRequestSize int64
+	scans          chan struct{}
+}
+
+//NewServer returns a Server accepting request bodies of up to maxRequestSize bytes and running up to maxConcurrentScans scans at once
+func NewServer(maxRequestSize int64, maxConcurrentScans int) *Server {
+	if maxConcurrentScans < 1 {
+		maxConcurrentScans = 1
+	}
+	return &Server{maxRequestSize: maxRequestSize, scans: make(chan struct{}, maxConcurrentScans)}
+}
+
+//Handler returns the handler serving the endpoints of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.health)
+	mux.HandleFunc("/scan/payload", s.scanning(s.scanPayload))
+	mux.HandleFunc("/scan/files", s.scanning(s.scanFiles))
+	mux.HandleFunc("/scan/range", s.scanning(s.scanRange))
+	return mux
+}
+
+//ListenAndServe serves the endpoints of the server on the address until it fails
+func (s *Server) ListenAndServe(address string) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Infof("Listening on %s", address)
+	return server.ListenAndServe()
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": Version})
+}
+
+//scanning wraps a scan endpoint, limiting its method, the size of its body and the number of scans running at once.
+//Requests sent by browsers, which carry an Origin header, are rejected so that web pages cannot use the server.
+func (s *Server) scanning(scan func(*http.Request) (*detector.DetectionResults, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"only POST is supported"})
+			return
+		}
+		if r.Header.Get("Origin") != "" {
+			writeJSON(w, http.StatusForbidden, errorResponse{"cross-origin requests are not supported"})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestSize)
+		select {
+		case s.scans <- struct{}{}:
+			defer func() { <-s.scans }()
+		case <-r.Context().Done():
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{"gave up waiting for a scan to finish"})
+			return
+		}
+		results, status, err := scan(r)
+		if err != nil {
+			writeJSON(w, status, errorResponse{err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, results)
+	}
+}
+
+//scanPayload scans the request body as a single file, named by the name query parameter
+func (s *Server) scanPayload(r *http.Request) (*detector.DetectionResults, int, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return requestBodyError(err)
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = DefaultStdinName
+	}
+	return scanInMemory([]ScanFile{{Path: name, Content: string(data)}}, nil), http.StatusOK, nil
+}
+
+func (s *Server) scanFiles(r *http.Request) (*detector.DetectionResults, int, error) {
+	var request FilesRequest
+	if status, err := decodeJSON(r, &request); err != nil {
+		return nil, status, err
+	}
+	return scanInMemory(request.Files, []byte(request.TalismanRC)), http.StatusOK, nil
+}
+
+func (s *Server) scanRange(r *http.Request) (*detector.DetectionResults, int, error) {
+	var request RangeRequest
+	if status, err := decodeJSON(r, &request); err != nil {
+		return nil, status, err
+	}
+	if !filepath.IsAbs(request.Repository) {
+		return nil, http.StatusBadRequest, fmt.Errorf("repository must be an absolute path")
+	}
+	if info, err := os.Stat(request.Repository); err != nil || !info.IsDir() {
+		return nil, http.StatusNotFound, fmt.Errorf("repository %s does not exist", request.Repository)
+	}
+	repo := git_repo.RepoContaining(request.Repository).WithFullFiles(true)
+	if request.From == "" {
+		request.From = git_repo.EmptyTreeSha
+	} else if !repo.HasCommit(request.From) {
+		return nil, http.StatusNotFound, fmt.Errorf("commit %s not found in %s", request.From, repo.Root())
+	}
+	if request.To == "" || !repo.HasCommit(request.To) {
+		return nil, http.StatusNotFound, fmt.Errorf("commit %s not found in %s", request.To, repo.Root())
+	}
+	additions, err := repo.AdditionsWithinRangeOrError(request.From, request.To)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	//the .talismanrc of the working tree may not be the one that applies to the commit, or be one nobody committed
+	talismanRC := repo.ReadCommitFileOrNothing(request.To, detector.DefaultRCFileName)
+	return testAdditions(detector.DefaultChain(), additions, detector.NewTalismanRCIgnore(talismanRC)), http.StatusOK, nil
+}
+
+func scanInMemory(files []ScanFile, talismanRC []byte) *detector.DetectionResults {
+	additions := make([]git_repo.Addition, len(files))
+	for i, file := range files {
+		additions[i] = git_repo.NewAddition(file.Path, []byte(file.Content))
+	}
+	return testAdditions(detector.DefaultChain(), additions, detector.NewTalismanRCIgnore(talismanRC))
+}
+
+func testAdditions(chain *detector.Chain, additions []git_repo.Addition, rc detector.TalismanRCIgnore) *detector.DetectionResults {
+	results := detector.NewDetectionResults()
+	results.SetRedactionPolicy(rc.Redaction)
+	chain.Test(additions, rc, results)
+	return results
+}
+
+//decodeJSON decodes the body of the request into the value, provided it is declared as JSON
+func decodeJSON(r *http.Request, value interface{}) (int, error) {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("only application/json request bodies are supported")
+	}
+	if err := json.NewDecoder(r.Body).Decode(value); err != nil {
+		_, status, err := requestBodyError(err)
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+func requestBodyError(err error) (*detector.DetectionResults, int, error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, err
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("malformed request: %v", err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Errorf("Unable to write response: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"talisman/detector"
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postToServer(t *testing.T, server *Server, path string, body string) (int, *detector.DetectionResults) {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	return sendToServer(t, server, request)
+}
+
+func sendToServer(t *testing.T, server *Server, request *http.Request) (int, *detector.DetectionResults) {
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		return recorder.Code, nil
+	}
+	results := detector.NewDetectionResults()
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), results))
+	return recorder.Code, results
+}
+
+func TestServerShouldReportHealth(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer(DefaultMaxRequestSize, 1).Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"status":"ok"`)
+}
+
+func TestServerShouldScanPayloads(t *testing.T) {
+	server := NewServer(DefaultMaxRequestSize, 1)
+
+	status, results := postToServer(t, server, "/scan/payload?name=secret.yaml", awsAccessKeyIDExample)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, results.HasFailures())
+	assert.Equal(t, "secret.yaml", string(results.Results[0].Filename))
+
+	_, results = postToServer(t, server, "/scan/payload", "nothing to see here")
+	assert.False(t, results.HasFailures())
+}
+
+func TestServerShouldScanFileSetsWithTheirTalismanRC(t *testing.T) {
+	server := NewServer(DefaultMaxRequestSize, 1)
+	files := `"files": [{"path": "readme.txt", "content": "nothing to see here"}, {"path": "private.pem", "content": "secret"}]`
+
+	_, results := postToServer(t, server, "/scan/files", "{"+files+"}")
+	assert.True(t, results.HasFailures(), "Expected the pem file to fail the scan")
+
+	talismanRC := `"talismanrc": "fileignoreconfig:\n- filename: private.pem\n  ignore_detectors: [filename]\n"`
+	_, results = postToServer(t, server, "/scan/files", "{"+files+", "+talismanRC+"}")
+	assert.False(t, results.HasFailures(), "Expected the pem file to be ignored")
+}
+
+func TestServerShouldScanCommitRangesOfLocalRepositories(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		server := NewServer(DefaultMaxRequestSize, 1)
+
+		status, results := postToServer(t, server, "/scan/range", `{"repository": "`+git.GetRoot()+`", "from": "`+baseline+`", "to": "`+git.LatestCommit()+`"}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, results.HasFailures(), "Expected the pem file added in the range to fail the scan")
+
+		_, results = postToServer(t, server, "/scan/range", `{"repository": "`+git.GetRoot()+`", "to": "`+baseline+`"}`)
+		assert.False(t, results.HasFailures(), "Expected the baseline commit to be clean")
+
+		status, _ = postToServer(t, server, "/scan/range", `{"repository": "`+git.GetRoot()+`", "to": "no-such-commit"}`)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServerShouldApplyTheTalismanRCCommittedInTheRange(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: private.pem\n  ignore_detectors: [filename, filecontent]\n")
+		server := NewServer(DefaultMaxRequestSize, 1)
+
+		_, results := postToServer(t, server, "/scan/range", `{"repository": "`+git.GetRoot()+`", "from": "`+baseline+`", "to": "`+git.LatestCommit()+`"}`)
+		assert.True(t, results.HasFailures(), "Expected the uncommitted .talismanrc not to apply to the range")
+	})
+}
+
+func TestServerShouldReportGitFailuresAsServerErrors(t *testing.T) {
+	withNewTmpGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		blob := git.ExecCommand("git", "rev-parse", "HEAD:private.pem")
+		os.Remove(filepath.Join(git.GetRoot(), ".git", "objects", blob[:2], blob[2:]))
+		server := NewServer(DefaultMaxRequestSize, 1)
+
+		status, _ := postToServer(t, server, "/scan/range", `{"repository": "`+git.GetRoot()+`", "from": "`+baseline+`", "to": "`+git.LatestCommit()+`"}`)
+		assert.Equal(t, http.StatusInternalServerError, status, "Expected the missing blob to fail the request rather than the server")
+	})
+}
+
+func TestServerShouldRejectInvalidRequests(t *testing.T) {
+	status, _ := postToServer(t, NewServer(16, 1), "/scan/payload", strings.Repeat("a", 17))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	server := NewServer(DefaultMaxRequestSize, 1)
+	status, _ = postToServer(t, server, "/scan/files", "{")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = postToServer(t, server, "/scan/range", `{"repository": "relative/path", "to": "HEAD"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scan/payload", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	status, _ = sendToServer(t, server, httptest.NewRequest(http.MethodPost, "/scan/files", strings.NewReader(`{"files": []}`)))
+	assert.Equal(t, http.StatusUnsupportedMediaType, status, "Expected a body not declared as JSON to be rejected")
+
+	fromBrowser := httptest.NewRequest(http.MethodPost, "/scan/payload", strings.NewReader("nothing to see here"))
+	fromBrowser.Header.Set("Origin", "https://example.com")
+	status, _ = sendToServer(t, server, fromBrowser)
+	assert.Equal(t, http.StatusForbidden, status, "Expected requests from web pages to be rejected")
+}
+
+func TestServerShouldHandleConcurrentScans(t *testing.T) {
+	server := NewServer(DefaultMaxRequestSize, 2)
+	var wg sync.WaitGroup
+	failures := make([]bool, 8)
+	for i := range failures {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results := postToServer(t, server, "/scan/payload", awsAccessKeyIDExample)
+			failures[i] = results != nil && results.HasFailures()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []bool{true, true, true, true, true, true, true, true}, failures)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"talisman/git_repo"
+)
+
+//DefaultStdinName is the name content read from stdin is reported and ignored as, unless another name is supplied
+const DefaultStdinName = "stdin"
+
+//StdinHook provides the content piped into talisman as a single addition, named so that it can be reported and ignored like a file
+type StdinHook struct {
+	stdin io.Reader
+	name  string
+}
+
+func NewStdinHook(stdin io.Reader, name string) *StdinHook {
+	if name == "" {
+		name = DefaultStdinName
+	}
+	return &StdinHook{stdin: stdin, name: name}
+}
+
+func (p *StdinHook) GetAdditions() ([]git_repo.Addition, []ReadError) {
+	data, err := ioutil.ReadAll(p.stdin)
+	if err != nil {
+		return nil, []ReadError{{Path: p.name, Err: err}}
+	}
+	addition := git_repo.NewAddition(p.name, data)
+	return []git_repo.Addition{addition}, nil
+}
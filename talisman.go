@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"strings"
 	"talisman/git_repo"
+	"talisman/report"
+	"talisman/scanner"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -23,6 +26,23 @@ var (
 	scan     bool
 	checksum string
 	reportdirectory string
+	interactive     bool
+	showSecrets     bool
+	scanRange       string
+	scanOptions     scanner.Options
+	recurseSubmodules bool
+	contextLines      int
+	fullFiles         bool
+	readStdin         bool
+	stdinName         string
+	filesFrom         string
+	plugins           bool
+	aggressive        bool
+	listenAddress      string
+	maxRequestSize     int64
+	maxConcurrentScans int
+	outputFormat       string
+	outputFile         string
 )
 
 const (
@@ -30,6 +50,12 @@ const (
 	PrePush = "pre-push"
 	//PreCommit : Const for name of of pre-commit hook
 	PreCommit = "pre-commit"
+	//ScanDirCommand : Const for the command scanning a directory tree without git
+	ScanDirCommand = "scan-dir"
+	//ServeCommand : Const for the command running talisman as an HTTP service
+	ServeCommand = "serve"
+	//LanguageServerCommand : Const for the command running talisman as a language server over stdin and stdout
+	LanguageServerCommand = "lsp"
 )
 
 func init() {
@@ -43,6 +69,25 @@ type options struct {
 	scan     bool
 	checksum string
 	reportdirectory string
+	interactive     bool
+	showSecrets     bool
+	scanOptions     scanner.Options
+	recurseSubmodules bool
+	contextLines      int
+	fullFiles         bool
+	scanDirectory     string
+	readStdin         bool
+	stdinName         string
+	filesFrom         string
+	plugins           bool
+	aggressive        bool
+	serve              bool
+	languageServer     bool
+	listenAddress      string
+	maxRequestSize     int64
+	maxConcurrentScans int
+	outputFormat       string
+	outputFile         string
 }
 
 //Logger is the default log device, set to emit at the Error level by default
@@ -60,6 +105,31 @@ func main() {
 	flag.StringVar(&checksum, "checksum", "", "checksum calculator calculates checksum and suggests .talsimarc format")
 	flag.StringVar(&reportdirectory, "reportdirectory", "", "directory where the scan reports will be stored")
 	flag.StringVar(&reportdirectory, "rd", "", "short form of report directory")
+	flag.BoolVar(&interactive, "i", false, "short form of interactive")
+	flag.BoolVar(&interactive, "interactive", false, "interactively resolve pre-commit failures (only when a terminal is attached)")
+	flag.StringVar(&scanRange, "scan-range", "", "revision range the scanner is restricted to, e.g. origin/master..HEAD")
+	flag.StringSliceVar(&scanOptions.Revisions, "scan-refs", nil, "refs the scanner is restricted to instead of all refs, e.g. master")
+	flag.StringVar(&scanOptions.Since, "since", "", "scanner only scans commits more recent than this date")
+	flag.StringVar(&scanOptions.Until, "until", "", "scanner only scans commits older than this date")
+	flag.StringSliceVar(&scanOptions.IncludePaths, "scan-include", nil, "globs of paths the scanner is restricted to")
+	flag.StringSliceVar(&scanOptions.ExcludePaths, "scan-exclude", nil, "globs of paths the scanner skips")
+	flag.IntVar(&scanOptions.MaxCommits, "max-commits", 0, "maximum number of commits the scanner walks (0 means no limit)")
+	flag.StringSliceVar(&scanOptions.Scopes, "scan-scope", nil, "additional objects the scanner scans: stash, reflog and/or unreachable")
+	flag.BoolVar(&scanOptions.NoCache, "no-cache", false, "scanner scans every blob again instead of reusing the findings cached by previous scans")
+	flag.BoolVar(&recurseSubmodules, "recurse-submodules", false, "scanner and pre-push hook also scan the checked out submodules")
+	flag.IntVar(&contextLines, "context-lines", git_repo.DefaultContextLines, "number of unchanged lines the hooks read around every change, for secrets spanning several lines")
+	flag.BoolVar(&fullFiles, "full-files", false, "hooks scan the whole new version of every changed file instead of the changed lines only")
+	flag.BoolVar(&readStdin, "stdin", false, "scan the content piped into talisman instead of git changes")
+	flag.StringVar(&stdinName, "name", DefaultStdinName, "file name the content read with --stdin is reported and ignored as")
+	flag.StringVar(&filesFrom, "files-from", "", "scan the files of a NUL-separated list read from this file, or from stdin if -")
+	flag.BoolVar(&plugins, "plugins", false, "run the detector plugins configured in .talismanrc, only when its content can be trusted")
+	flag.BoolVar(&aggressive, "aggressive", false, "also flag shorter texts that decode as base64, at the cost of more false positives")
+	flag.StringVar(&listenAddress, "listen", DefaultListenAddress, "address talisman serve listens on")
+	flag.Int64Var(&maxRequestSize, "max-request-size", DefaultMaxRequestSize, "largest request body talisman serve accepts, in bytes")
+	flag.IntVar(&maxConcurrentScans, "max-concurrent-scans", runtime.NumCPU(), "number of scans talisman serve runs at the same time")
+	flag.StringVar(&outputFormat, "output-format", report.TableFormat, "format the hooks, pattern and scan print their results in: "+strings.Join(report.Formats(), ", "))
+	flag.StringVar(&outputFile, "output-file", "", "file the results in the --output-format are written to instead of stdout (--output-format then defaults to json)")
+	flag.BoolVar(&showSecrets, "show-secrets", false, "show detected secrets in full instead of redacting them (for local debugging only)")
 
 	flag.Parse()
 
@@ -68,7 +138,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	if flag.NFlag() == 0 {
+	if flag.NFlag() == 0 && flag.NArg() == 0 {
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
@@ -80,6 +150,37 @@ func main() {
 		scan:     scan,
 		checksum: checksum,
 		reportdirectory: reportdirectory,
+		interactive:     interactive,
+		showSecrets:     showSecrets,
+		scanOptions:     scanOptions,
+		recurseSubmodules: recurseSubmodules,
+		contextLines:      contextLines,
+		fullFiles:         fullFiles,
+		readStdin:         readStdin,
+		stdinName:         stdinName,
+		filesFrom:         filesFrom,
+		plugins:           plugins,
+		aggressive:        aggressive,
+		listenAddress:      listenAddress,
+		maxRequestSize:     maxRequestSize,
+		maxConcurrentScans: maxConcurrentScans,
+		outputFormat:       outputFormat,
+		outputFile:         outputFile,
+	}
+	if scanRange != "" {
+		_options.scanOptions.Revisions = append(_options.scanOptions.Revisions, scanRange)
+	}
+	switch flag.Arg(0) {
+	case ScanDirCommand:
+		if flag.NArg() != 2 {
+			fmt.Fprintf(os.Stderr, "Usage: talisman %s <path>\n", ScanDirCommand)
+			os.Exit(2)
+		}
+		_options.scanDirectory = flag.Arg(1)
+	case ServeCommand:
+		_options.serve = true
+	case LanguageServerCommand:
+		_options.languageServer = true
 	}
 
 	os.Exit(run(os.Stdin, _options))
@@ -96,28 +197,86 @@ func run(stdin io.Reader, _options options) (returnCode int) {
 		_options.githook = PrePush
 	}
 
+	if _options.outputFile != "" && (_options.outputFormat == "" || _options.outputFormat == report.TableFormat) {
+		_options.outputFormat = "json"
+	}
+	if _, err := report.ReporterFor(_options.outputFormat, report.Run{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --output-format: %v\n", err)
+		return CompletedWithErrors
+	}
+
 	var additions []git_repo.Addition
 	if _options.checksum != "" {
 		log.Infof("Running %s patterns against checksum calculator", _options.checksum)
 		return NewRunner(make([]git_repo.Addition, 0)).RunChecksumCalculator(strings.Fields(_options.checksum))
 	} else if _options.scan {
 		log.Infof("Running scanner")
-		return NewRunner(make([]git_repo.Addition, 0)).Scan(_options.reportdirectory)
+		scanOptions := _options.scanOptions
+		scanOptions.RecurseSubmodules = _options.recurseSubmodules
+		return newRunner(make([]git_repo.Addition, 0), _options).Scan(_options.reportdirectory, scanOptions)
+	} else if _options.languageServer {
+		if err := NewLanguageServer(stdin, os.Stdout).Serve(); err != nil {
+			log.Errorf("Language server failed: %v", err)
+			return CompletedWithErrors
+		}
+		return CompletedSuccessfully
+	} else if _options.serve {
+		server := NewServer(_options.maxRequestSize, _options.maxConcurrentScans)
+		fmt.Fprintf(os.Stderr, "Serving talisman on http://%s\n", _options.listenAddress)
+		if err := server.ListenAndServe(_options.listenAddress); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to serve: %v\n", err)
+		}
+		return CompletedWithErrors
+	} else if _options.scanDirectory != "" {
+		log.Infof("Scanning directory %s", _options.scanDirectory)
+		additions, readErrors := NewDirectoryScan(_options.scanDirectory).GetAdditions()
+		return newRunner(additions, _options).ReadErrors(readErrors).RunWithoutErrors()
+	} else if _options.readStdin {
+		log.Infof("Scanning stdin as %s", _options.stdinName)
+		additions, readErrors := NewStdinHook(stdin, _options.stdinName).GetAdditions()
+		return newRunner(additions, _options).ReadErrors(readErrors).RunWithoutErrors()
+	} else if _options.filesFrom != "" {
+		log.Infof("Scanning files listed in %s", _options.filesFrom)
+		list := stdin
+		if _options.filesFrom != "-" {
+			file, err := os.Open(_options.filesFrom)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to read file list: %v\n", err)
+				return CompletedWithErrors
+			}
+			defer file.Close()
+			list = file
+		}
+		additions, readErrors := NewFileListHook(list).GetAdditions()
+		return newRunner(additions, _options).ReadErrors(readErrors).RunWithoutErrors()
 	} else if _options.pattern != "" {
 		log.Infof("Running %s pattern", _options.pattern)
 		directoryHook := NewDirectoryHook()
 		additions = directoryHook.GetFilesFromDirectory(_options.pattern)
 	} else if _options.githook == PreCommit {
 		log.Infof("Running %s hook", _options.githook)
-		preCommitHook := NewPreCommitHook()
+		preCommitHook := NewPreCommitHook().ReadingChanges(_options.contextLines, _options.fullFiles)
 		additions = preCommitHook.GetRepoAdditions()
+		if _options.interactive {
+			if tty, ok := openTerminal(); ok {
+				defer tty.Close()
+				wd, _ := os.Getwd()
+				remediation := NewInteractiveRemediation(git_repo.RepoContaining(wd), tty, tty)
+				return newRunner(additions, _options).RunInteractively(remediation, preCommitHook.GetRepoAdditions)
+			}
+			log.Info("No terminal attached, running without interactive remediation")
+		}
 	} else {
 		log.Infof("Running %s hook", _options.githook)
-		prePushHook := NewPrePushHook(readRefAndSha(stdin))
+		prePushHook := NewPrePushHook(readRefAndSha(stdin)).RecurseSubmodules(_options.recurseSubmodules).ReadingChanges(_options.contextLines, _options.fullFiles)
 		additions = prePushHook.GetRepoAdditions()
 	}
 
-	return NewRunner(additions).RunWithoutErrors()
+	return newRunner(additions, _options).RunWithoutErrors()
+}
+
+func newRunner(additions []git_repo.Addition, _options options) *Runner {
+	return NewRunner(additions).ShowSecrets(_options.showSecrets).WithPlugins(_options.plugins).Aggressive(_options.aggressive).OutputFormat(_options.outputFormat).OutputFile(_options.outputFile)
 }
 
 func readRefAndSha(file io.Reader) (string, string, string, string) {
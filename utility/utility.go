@@ -21,14 +21,23 @@ func UniqueItems(stringSlice []string) []string {
 
 //CollectiveSHA256Hash return collective sha256 hash of the passed paths
 func CollectiveSHA256Hash(paths []string) string {
+	contents := make([][]byte, len(paths))
+	for i, path := range paths {
+		contents[i], _ = ioutil.ReadFile(path)
+	}
+	return CollectiveSHA256HashOfContents(paths, contents)
+}
+
+//CollectiveSHA256HashOfContents returns the hash CollectiveSHA256Hash would return for the paths if the files at those paths had the passed contents,
+//e.g. for content read from git objects rather than from the working tree
+func CollectiveSHA256HashOfContents(paths []string, contents [][]byte) string {
 	var finHash = ""
-	for _, path := range paths {
+	for i, path := range paths {
 		sbyte := []byte(finHash)
 		concatBytes := hashByte(&sbyte)
 		nameByte := []byte(path)
 		nameHash := hashByte(&nameByte)
-		fileBytes, _ := ioutil.ReadFile(path)
-		fileHash := hashByte(&fileBytes)
+		fileHash := hashByte(&contents[i])
 		finHash = concatBytes + fileHash + nameHash
 	}
 	c := []byte(finHash)